@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/mrtc0/genv"
+	"github.com/spf13/cobra"
+)
+
+// addEnvFilterFlags adds the flags that select the envs to the command.
+func addEnvFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("only", nil, "Only the envs with the names, e.g. API_KEY,API_URL")
+	cmd.Flags().StringSlice("exclude", nil, "Skip the envs with the names")
+	cmd.Flags().StringSlice("tag", nil, "Only the envs with any of the tags, e.g. backend")
+}
+
+// envFilterFromFlags returns the filter given with the flags added by
+// addEnvFilterFlags.
+func envFilterFromFlags(cmd *cobra.Command) (genv.EnvFilter, error) {
+	var (
+		f   genv.EnvFilter
+		err error
+	)
+
+	if f.Only, err = cmd.Flags().GetStringSlice("only"); err != nil {
+		return f, err
+	}
+	if f.Exclude, err = cmd.Flags().GetStringSlice("exclude"); err != nil {
+		return f, err
+	}
+	if f.Tags, err = cmd.Flags().GetStringSlice("tag"); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
@@ -1,53 +1,207 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/mrtc0/genv"
 	"github.com/mrtc0/genv/dotenv"
+	"github.com/mrtc0/genv/format"
+	"github.com/mrtc0/genv/internal/fileutil"
 	"github.com/spf13/cobra"
 )
 
 var (
 	genvFilePath   string
 	outputFilePath string
+	concurrency    int
+	profile        string
+	fileMode       string
+	followSymlinks bool
+	merge          bool
+	outputFormat   string
+	k8sName        string
+	k8sNamespace   string
+	k8sLabels      map[string]string
+	k8sConfigMap   string
 )
 
+// stdoutPath is the output path that writes to the standard output.
+const stdoutPath = "-"
+
 var genCmd = &cobra.Command{
 	Use:   "gen",
 	Short: "Generate .env file",
 	Long:  `Generate .env file from secret providers.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := cmd.Context()
+		ctx, cancel := withTimeout(cmd.Context())
+		defer cancel()
+
+		if err := format.CheckName(outputFormat); err != nil {
+			return err
+		}
+		if merge && (outputFormat != format.Dotenv || outputFilePath == stdoutPath) {
+			return fmt.Errorf("--merge can only be used with the %s format and an output file", format.Dotenv)
+		}
+
+		output := outputFilePath
+		if outputFormat == format.GitHubActions && !cmd.Flags().Changed("output") {
+			output = os.Getenv("GITHUB_ENV")
+			if output == "" {
+				return fmt.Errorf("GITHUB_ENV is not set, specify the output file with --output")
+			}
+		}
+
+		writeOpts := fileutil.WriteOptions{FollowSymlinks: followSymlinks}
+		if fileMode != "" {
+			mode, err := strconv.ParseUint(fileMode, 8, 32)
+			if err != nil || mode > 0777 {
+				return fmt.Errorf("invalid file mode: %s", fileMode)
+			}
+			writeOpts.Mode = os.FileMode(mode)
+		}
 
-		cfg, err := genv.LoadConfig(genvFilePath)
+		filter, err := envFilterFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := genv.LoadConfig(genvFilePath, genv.WithProfile(profile))
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		cfg = cfg.Filter(filter)
+
+		if concurrency > 0 {
+			cfg.Concurrency = concurrency
+		}
+
+		encoder, err := newEncoder(cmd, cfg)
+		if err != nil {
+			return err
+		}
 
 		generator, err := genv.NewDotenvGenerator(ctx, genv.DotenvGeneratorConfig{
 			Config:         cfg,
-			OutputFilePath: outputFilePath,
+			OutputFilePath: output,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create dotenv generator: %w", err)
 		}
+		defer generator.Close()
 
 		secrets, err := generator.FetchSecrets(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to generate .env file: %w", err)
 		}
 
-		if err := dotenv.WriteFile(outputFilePath, secrets); err != nil {
-			return fmt.Errorf("failed to write .env file: %w", err)
+		if merge {
+			doc, err := dotenv.ReadDocument(outputFilePath)
+			if err != nil {
+				return fmt.Errorf("failed to read .env file: %w", err)
+			}
+
+			// New keys are appended in sorted order
+			for _, key := range slices.Sorted(maps.Keys(secrets)) {
+				doc.Set(key, secrets[key])
+			}
+
+			if err := fileutil.WriteFile(outputFilePath, doc.Bytes(), writeOpts); err != nil {
+				return fmt.Errorf("failed to write .env file: %w", err)
+			}
+
+			return nil
+		}
+
+		var buf bytes.Buffer
+		if err := encoder.Encode(&buf, secrets); err != nil {
+			return fmt.Errorf("failed to encode envs: %w", err)
+		}
+
+		switch {
+		case output == stdoutPath:
+			if _, err := cmd.OutOrStdout().Write(buf.Bytes()); err != nil {
+				return err
+			}
+		case outputFormat == format.GitHubActions:
+			// The file is shared by the steps of the job, so it is appended to.
+			if err := fileutil.AppendFile(output, buf.Bytes(), writeOpts); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+		default:
+			if err := fileutil.WriteFile(output, buf.Bytes(), writeOpts); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+		}
+
+		if outputFormat == format.GitLab && slices.ContainsFunc(slices.Collect(maps.Keys(secrets)), cfg.IsSecretEnv) {
+			cmd.PrintErrln("warning: GitLab does not mask the values of dotenv artifacts; they may appear in job logs")
 		}
 
 		return nil
 	},
 }
 
+// newEncoder returns the encoder of the output format. The encoders of the
+// formats that need options are created from the config file and the flags.
+func newEncoder(cmd *cobra.Command, cfg *genv.Config) (format.Encoder, error) {
+	switch outputFormat {
+	case format.KubernetesSecret:
+		return newKubernetesSecretEncoder(cfg)
+	case format.GitHubActions:
+		// The masks must be written to the log before the values can appear in it.
+		return format.NewGitHubActionsEncoder(cmd.OutOrStdout(), cfg.IsSecretEnv)
+	default:
+		return format.Lookup(outputFormat)
+	}
+}
+
+// newKubernetesSecretEncoder returns the encoder of the k8s-secret format,
+// configured from the config file and the flags.
+func newKubernetesSecretEncoder(cfg *genv.Config) (format.Encoder, error) {
+	opts := format.KubernetesSecretOptions{
+		Name:      cfg.Kubernetes.Name,
+		Namespace: cfg.Kubernetes.Namespace,
+		Labels:    maps.Clone(cfg.Kubernetes.Labels),
+		ConfigMap: cfg.Kubernetes.ConfigMap,
+		IsSecret:  cfg.IsSecretEnv,
+	}
+
+	if k8sName != "" {
+		opts.Name = k8sName
+	}
+	if k8sNamespace != "" {
+		opts.Namespace = k8sNamespace
+	}
+	if k8sConfigMap != "" {
+		opts.ConfigMap = k8sConfigMap
+	}
+	if len(k8sLabels) > 0 && opts.Labels == nil {
+		opts.Labels = make(map[string]string, len(k8sLabels))
+	}
+	maps.Copy(opts.Labels, k8sLabels)
+
+	return format.NewKubernetesSecretEncoder(opts)
+}
+
 func init() {
 	genCmd.Flags().StringVar(&genvFilePath, "config", ".genv.yaml", "Path to the genv config file")
-	genCmd.Flags().StringVar(&outputFilePath, "output", ".env", "Path to the output dotenv file")
+	genCmd.Flags().StringVar(&profile, "profile", "", "Name of the profile to apply to the genv config file")
+	genCmd.Flags().StringVar(&outputFilePath, "output", ".env", "Path to the output file, or - to write to the standard output")
+	genCmd.Flags().IntVar(&concurrency, "concurrency", 0, "Maximum number of secrets fetched concurrently (overrides the config file)")
+	genCmd.Flags().StringVar(&fileMode, "file-mode", "", "Mode of the output file in octal, e.g. 0640 (default: the mode of the existing file, or 0600)")
+	genCmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "Write to the target if the output file is a symbolic link, instead of failing")
+	genCmd.Flags().BoolVar(&merge, "merge", false, "Update only the generated envs in the existing dotenv file, keeping its comments, order and other envs")
+	genCmd.Flags().StringVar(&outputFormat, "format", format.Dotenv, "Format of the output: "+strings.Join(format.Names(), ", "))
+	genCmd.Flags().StringVar(&k8sName, "k8s-name", "", "Name of the Secret for the k8s-secret format (overrides the config file)")
+	genCmd.Flags().StringVar(&k8sNamespace, "k8s-namespace", "", "Namespace of the manifests for the k8s-secret format (overrides the config file)")
+	genCmd.Flags().StringToStringVar(&k8sLabels, "k8s-label", nil, "Labels of the manifests for the k8s-secret format, e.g. app=web (merged with the config file)")
+	genCmd.Flags().StringVar(&k8sConfigMap, "k8s-configmap", "", "Name of the ConfigMap to put the envs with plain values in, for the k8s-secret format (overrides the config file)")
+	addEnvFilterFlags(genCmd)
 	rootCmd.AddCommand(genCmd)
 }
@@ -13,6 +13,7 @@ import (
 var (
 	dotenvFilePath string
 	ignoreValue    bool
+	compareProfile string
 )
 
 var outdatedCmd = &cobra.Command{
@@ -20,12 +21,44 @@ var outdatedCmd = &cobra.Command{
 	Short: "Show outdated envs in the dotenv file.",
 	Long:  `Show the difference between the current genv environment variable definitions and the dotenv file.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := cmd.Context()
+		ctx, cancel := withTimeout(cmd.Context())
+		defer cancel()
 
-		cfg, err := genv.LoadConfig(genvFilePath)
+		filter, err := envFilterFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := genv.LoadConfig(genvFilePath, genv.WithProfile(profile))
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		cfg = cfg.Filter(filter)
+
+		if compareProfile != "" {
+			other, err := genv.LoadConfig(genvFilePath, genv.WithProfile(compareProfile))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			other = other.Filter(filter)
+
+			diff, err := genv.DiffConfigEnvName(ctx, cfg, other)
+			if err != nil {
+				return fmt.Errorf("failed to diff envs: %w", err)
+			}
+
+			if !diff.IsChanged() {
+				return nil
+			}
+
+			fmt.Printf("%s\n", renderer.RenderDiff(diff))
+
+			return errors.New("envs differ between profiles")
+		}
+
+		if concurrency > 0 {
+			cfg.Concurrency = concurrency
+		}
 		dotenvMap, err := dotenv.ReadFile(dotenvFilePath)
 		if err != nil {
 			return fmt.Errorf("failed to read dotenv file: %w", err)
@@ -48,7 +81,11 @@ var outdatedCmd = &cobra.Command{
 
 func init() {
 	outdatedCmd.Flags().StringVar(&genvFilePath, "config", ".genv.yaml", "Path to the genv config file.")
+	outdatedCmd.Flags().StringVar(&profile, "profile", "", "Name of the profile to apply to the genv config file.")
+	outdatedCmd.Flags().StringVar(&compareProfile, "compare-profile", "", "Name of a profile to compare the names of the environment variables with, instead of the dotenv file. No values are retrieved.")
 	outdatedCmd.Flags().StringVar(&dotenvFilePath, "envfile", ".env", "Path to the dotenv file.")
+	outdatedCmd.Flags().IntVar(&concurrency, "concurrency", 0, "Maximum number of secrets fetched concurrently (overrides the config file).")
 	outdatedCmd.Flags().BoolVar(&ignoreValue, "ignore-value", false, "Only the differences in the variable names of the environment variables are checked. No values are retrieved from remote credential providers.")
+	addEnvFilterFlags(outdatedCmd)
 	rootCmd.AddCommand(outdatedCmd)
 }
@@ -1,22 +1,52 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"os"
+	"time"
 
+	"github.com/mrtc0/genv"
 	"github.com/mrtc0/genv/version"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
-	Use:          "genv",
-	Short:        "genv is a dotenv generator",
-	Long:         `genv is a dotenv generator that generates .env files from various secret providers.`,
-	Version:      version.Version,
-	SilenceUsage: true,
+	Use:           "genv",
+	Short:         "genv is a dotenv generator",
+	Long:          `genv is a dotenv generator that generates .env files from various secret providers.`,
+	Version:       version.Version,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// timeout limits the time to retrieve the secrets.
+var timeout time.Duration
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Maximum time to retrieve the secrets, e.g. 1m (default: no limit)")
+}
+
+// withTimeout returns the context to retrieve the secrets in, limited by
+// --timeout.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		// genv run exits with the exit status of the command, which has
+		// already reported its own errors.
+		var exitErr *genv.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+
+		rootCmd.PrintErrln(rootCmd.ErrPrefix(), err.Error())
 		os.Exit(1)
 	}
 }
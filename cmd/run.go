@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 
 	"github.com/mrtc0/genv"
@@ -12,42 +16,206 @@ import (
 var runCommand = &cobra.Command{
 	Use:   "run [options] [COMMAND [ARG...]]",
 	Short: "Run a command with environment variables from .env file",
-	Long:  `Run a command with environment variables loaded from a .env file.`,
+	Long: `Run a command with environment variables loaded from a .env file.
+
+With --from-config (or --config), the environment variables are retrieved from the secret providers
+defined in the genv config file instead, and are passed to the command without being written to disk.
+
+Signals received by genv are forwarded to the command, and genv exits with the exit status of the command.`,
 	Example: `genv run some-command
-genv run --envfile /path/to/.env some-command`,
+genv run --envfile /path/to/.env some-command
+genv run --from-config some-command
+genv run --config /path/to/.genv.yaml --profile prod some-command
+genv run --exec some-command
+genv run --clean-env --inherit PATH,HOME,LANG some-command
+genv run --from-config --tag frontend some-command`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: run,
 }
 
 func init() {
 	runCommand.Flags().StringP("envfile", "e", ".env", "Path to the .env file")
+	runCommand.Flags().Bool("from-config", false, "Retrieve the environment variables from the secret providers in the genv config file instead of the .env file")
+	runCommand.Flags().String("config", ".genv.yaml", "Path to the genv config file (implies --from-config)")
+	runCommand.Flags().String("profile", "", "Name of the profile to apply to the genv config file (implies --from-config)")
+	runCommand.Flags().Int("concurrency", 0, "Maximum number of secrets fetched concurrently (overrides the config file)")
+	runCommand.Flags().Bool("clean-env", false, "Start the command with only the generated environment variables and those listed in --inherit")
+	runCommand.Flags().StringSlice("inherit", nil, "Environment variables passed to the command with --clean-env, e.g. PATH,HOME,LC_* (a trailing * matches any suffix)")
+	runCommand.Flags().String("env-precedence", string(genv.EnvPrecedenceDotenv), `Which value is used when an environment variable is also set on the host: "dotenv" or "host"`)
+	runCommand.Flags().Bool("redact", false, "Replace the secret values in the output of the command with ***. Without --from-config, all the values in the .env file are replaced")
+	addEnvFilterFlags(runCommand)
+	runCommand.Flags().Bool("exec", false, "Replace the genv process with the command using exec(2) (not supported on Windows)")
+	// Flags after COMMAND are passed to the command
+	runCommand.Flags().SetInterspersed(false)
 
 	rootCmd.AddCommand(runCommand)
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	envFile, err := cmd.Flags().GetString("envfile")
+	fromConfig, err := cmd.Flags().GetBool("from-config")
+	if err != nil {
+		return err
+	}
+	fromConfig = fromConfig || cmd.Flags().Changed("config") || cmd.Flags().Changed("profile")
+
+	filter, err := envFilterFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	// secrets holds the values redacted with --redact.
+	var envMap map[string]string
+	var secrets []string
+	if fromConfig {
+		if cmd.Flags().Changed("envfile") {
+			return errors.New("--envfile cannot be used with --from-config, --config or --profile")
+		}
+
+		envMap, secrets, err = fetchEnvsFromConfig(cmd.Context(), cmd, filter)
+		if err != nil {
+			return err
+		}
+	} else {
+		envFile, err := cmd.Flags().GetString("envfile")
+		if err != nil {
+			return err
+		}
+
+		if len(filter.Tags) > 0 {
+			return errors.New("--tag can only be used with --from-config, --config or --profile")
+		}
+
+		envMap, err = dotenv.ReadFile(envFile)
+		if err != nil {
+			return fmt.Errorf("failed to read .env file: %w", err)
+		}
+		maps.DeleteFunc(envMap, func(name, _ string) bool {
+			return !filter.Match(name, nil)
+		})
+
+		// Which values are secrets is unknown, so all of them are redacted.
+		for _, v := range envMap {
+			secrets = append(secrets, v)
+		}
+	}
+
+	cleanEnv, err := cmd.Flags().GetBool("clean-env")
+	if err != nil {
+		return err
+	}
+	inherit, err := cmd.Flags().GetStringSlice("inherit")
+	if err != nil {
+		return err
+	}
+	if len(inherit) > 0 && !cleanEnv {
+		return errors.New("--inherit can only be used with --clean-env")
+	}
+	precedence, err := cmd.Flags().GetString("env-precedence")
 	if err != nil {
 		return err
 	}
 
-	envMap, err := dotenv.ReadFile(envFile)
+	execMode, err := cmd.Flags().GetBool("exec")
 	if err != nil {
-		return fmt.Errorf("failed to read .env file: %w", err)
+		return err
+	}
+	redact, err := cmd.Flags().GetBool("redact")
+	if err != nil {
+		return err
+	}
+	if redact && execMode {
+		return errors.New("--redact cannot be used with --exec")
+	}
+
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if redact {
+		stdoutRedactor := genv.NewRedactor(os.Stdout, secrets)
+		defer stdoutRedactor.Close()
+		stderrRedactor := genv.NewRedactor(os.Stderr, secrets)
+		defer stderrRedactor.Close()
+
+		stdout, stderr = stdoutRedactor, stderrRedactor
 	}
 
 	runner, err := genv.NewCommandRunner(genv.CommandRunnerConfig{
-		Name:   args[0],
-		Args:   args[1:],
-		Envs:   envMap,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
+		Name:       args[0],
+		Args:       args[1:],
+		Envs:       envMap,
+		CleanEnv:   cleanEnv,
+		Inherit:    inherit,
+		Precedence: genv.EnvPrecedence(precedence),
+		Stdin:      os.Stdin,
+		Stdout:     stdout,
+		Stderr:     stderr,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create command runner: %w", err)
 	}
 
+	if execMode {
+		return fmt.Errorf("failed to exec command: %w", runner.Exec())
+	}
+
 	if err := runner.Run(); err != nil {
+		var exitErr *genv.ExitError
+		if errors.As(err, &exitErr) {
+			// Pass the exit status of the command through as is.
+			return err
+		}
 		return fmt.Errorf("failed to run command: %w", err)
 	}
 	return nil
 }
+
+// fetchEnvsFromConfig retrieves the environment variables defined in the
+// genv config file. The values are only kept in memory.
+// The values of the envs that may come from secret providers are returned as
+// secrets.
+func fetchEnvsFromConfig(ctx context.Context, cmd *cobra.Command, filter genv.EnvFilter) (map[string]string, []string, error) {
+	configPath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return nil, nil, err
+	}
+	profile, err := cmd.Flags().GetString("profile")
+	if err != nil {
+		return nil, nil, err
+	}
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg, err := genv.LoadConfig(configPath, genv.WithProfile(profile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if concurrency > 0 {
+		cfg.Concurrency = concurrency
+	}
+	cfg = cfg.Filter(filter)
+
+	// The timeout only applies to retrieving the secrets, not to the command.
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	generator, err := genv.NewDotenvGenerator(ctx, genv.DotenvGeneratorConfig{Config: cfg})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create dotenv generator: %w", err)
+	}
+	defer generator.Close()
+
+	envMap, err := generator.FetchSecrets(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+
+	var secrets []string
+	for name, value := range envMap {
+		if cfg.IsSecretEnv(name) {
+			secrets = append(secrets, value)
+		}
+	}
+
+	return envMap, secrets, nil
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mrtc0/genv"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the genv config file",
+	Long: `Print the JSON Schema of the genv config file.
+Editors that support JSON Schema, such as yaml-language-server, can use it to complete and validate the config file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, err := genv.JSONSchema()
+		if err != nil {
+			return fmt.Errorf("failed to generate JSON Schema: %w", err)
+		}
+
+		_, err = cmd.OutOrStdout().Write(schema)
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mrtc0/genv"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the genv config file",
+	Long: `Validate the genv config file without retrieving any secrets.
+Unknown fields, undefined secret providers and invalid settings are reported with their line and column numbers.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := genv.LoadConfig(genvFilePath, genv.WithProfile(profile))
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err == nil {
+			return nil
+		}
+
+		problems := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			problems = joined.Unwrap()
+		}
+
+		for _, problem := range problems {
+			fmt.Fprintln(cmd.ErrOrStderr(), problem)
+		}
+
+		return fmt.Errorf("%s is invalid: %d problem(s) found", genvFilePath, len(problems))
+	},
+}
+
+func init() {
+	validateCmd.Flags().StringVar(&genvFilePath, "config", ".genv.yaml", "Path to the genv config file")
+	validateCmd.Flags().StringVar(&profile, "profile", "", "Name of the profile to apply to the genv config file")
+	rootCmd.AddCommand(validateCmd)
+}
@@ -1,14 +1,27 @@
 package genv
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
 )
 
 type CommandRunner interface {
-	// Run executes the command with the provided environment variables.
+	// Run executes the command with the provided environment variables and
+	// waits for it to exit. Signals received by genv are forwarded to the
+	// command. If the command exits with a non-zero status, an *ExitError is
+	// returned.
 	Run() error
+	// Exec replaces the genv process with the command using exec(2). It only
+	// returns if the command cannot be executed. The command inherits the
+	// standard streams of genv, so Stdin, Stdout and Stderr are ignored.
+	Exec() error
 }
 
 type commandRunner struct {
@@ -16,21 +29,63 @@ type commandRunner struct {
 }
 
 type CommandRunnerConfig struct {
-	Name   string
-	Args   []string
-	Envs   map[string]string
-	Stdout io.Writer
-	Stderr io.Writer
+	Name string
+	Args []string
+	Envs map[string]string
+	// CleanEnv starts the command with only Envs and the environment
+	// variables of genv listed in Inherit, instead of the whole environment
+	// of genv.
+	CleanEnv bool
+	// Inherit lists the environment variables of genv passed to the command
+	// when CleanEnv is set, e.g. PATH and HOME. A trailing "*" matches any
+	// suffix, e.g. LC_*.
+	Inherit []string
+	// Precedence decides which value is used when an env in Envs is also
+	// set in the environment of genv. If omitted, defaults to
+	// EnvPrecedenceDotenv.
+	Precedence EnvPrecedence
+	Stdin      io.Reader
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+type EnvPrecedence string
+
+const (
+	// EnvPrecedenceDotenv gives the values in Envs precedence over the
+	// environment of genv.
+	EnvPrecedenceDotenv EnvPrecedence = "dotenv"
+	// EnvPrecedenceHost gives the environment of genv precedence over the
+	// values in Envs.
+	EnvPrecedenceHost EnvPrecedence = "host"
+)
+
+// ExitError is returned by CommandRunner.Run when the command exits with a
+// non-zero status.
+type ExitError struct {
+	// Code is the exit status of the command. If the command was killed by a
+	// signal, it is 128 plus the signal number, as in shells.
+	Code int
+	Err  *exec.ExitError
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d: %v", e.Code, e.Err)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
 }
 
 func NewCommandRunner(cfg CommandRunnerConfig) (CommandRunner, error) {
-	envs := make([]string, 0, len(cfg.Envs))
-	for k, v := range cfg.Envs {
-		envs = append(envs, k+"="+v)
+	envs, err := commandEnv(os.Environ(), cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	command := exec.Command(cfg.Name, cfg.Args...)
-	command.Env = append(os.Environ(), envs...)
+	command.Env = envs
+	command.Stdin = cfg.Stdin
 	command.Stdout = cfg.Stdout
 	command.Stderr = cfg.Stderr
 
@@ -40,5 +95,126 @@ func NewCommandRunner(cfg CommandRunnerConfig) (CommandRunner, error) {
 }
 
 func (c *commandRunner) Run() error {
-	return c.cmd.Run()
+	group := !isTerminal(c.cmd.Stdin)
+	if group {
+		// Run the command in its own process group so that signals can be
+		// forwarded to the processes it spawns as well. The command shares
+		// the process group of genv when it is attached to a terminal, since
+		// only the foreground process group can read from the terminal, and
+		// receives the signals from the terminal directly.
+		setProcessGroup(c.cmd)
+	}
+
+	// Start listening before the command starts so that no signal is missed.
+	signals := make(chan os.Signal, 16)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := c.cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				// The command may have already exited.
+				_ = forwardSignal(c.cmd.Process, sig, group)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := c.cmd.Wait()
+	close(done)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Code: exitCode(exitErr), Err: exitErr}
+	}
+
+	return err
+}
+
+func (c *commandRunner) Exec() error {
+	if c.cmd.Err != nil {
+		return c.cmd.Err
+	}
+
+	return execProcess(c.cmd.Path, c.cmd.Args, c.cmd.Env)
+}
+
+// commandEnv returns the environment of the command, sorted by name, from the
+// environment of genv and the config.
+func commandEnv(host []string, cfg CommandRunnerConfig) ([]string, error) {
+	if !cfg.CleanEnv && len(cfg.Inherit) > 0 {
+		return nil, errors.New("inherit can only be used with clean env")
+	}
+
+	hostEnvs := make(map[string]string, len(host))
+	for _, kv := range host {
+		k, v := splitEnv(kv)
+		if cfg.CleanEnv && !slices.ContainsFunc(cfg.Inherit, func(pattern string) bool { return matchEnvName(pattern, k) }) {
+			continue
+		}
+		hostEnvs[k] = v
+	}
+
+	merged := make(map[string]string, len(hostEnvs)+len(cfg.Envs))
+	switch cfg.Precedence {
+	case "", EnvPrecedenceDotenv:
+		maps.Copy(merged, hostEnvs)
+		maps.Copy(merged, cfg.Envs)
+	case EnvPrecedenceHost:
+		maps.Copy(merged, cfg.Envs)
+		maps.Copy(merged, hostEnvs)
+	default:
+		return nil, fmt.Errorf("unsupported env precedence: %s", cfg.Precedence)
+	}
+
+	envs := make([]string, 0, len(merged))
+	for _, k := range slices.Sorted(maps.Keys(merged)) {
+		envs = append(envs, k+"="+merged[k])
+	}
+
+	return envs, nil
+}
+
+// splitEnv splits "NAME=value" into the name and the value. On Windows, the
+// name may start with "=", e.g. "=C:=C:\\work".
+func splitEnv(kv string) (string, string) {
+	i := strings.Index(kv, "=")
+	if i == 0 {
+		i = strings.Index(kv[1:], "=") + 1
+	}
+	if i <= 0 {
+		return kv, ""
+	}
+
+	return kv[:i], kv[i+1:]
+}
+
+func matchEnvName(pattern, name string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+
+	return pattern == name
+}
+
+// isTerminal reports whether r is a terminal.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
 }
@@ -2,10 +2,12 @@ package genv_test
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/mrtc0/genv"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCommandRunner_Run(t *testing.T) {
@@ -34,3 +36,120 @@ func TestCommandRunner_Run(t *testing.T) {
 
 	assert.Empty(t, stderr.String())
 }
+
+func TestCommandRunner_Run_ExitCode(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		script   string
+		expected int // 0 means no error expected
+	}{
+		"success":           {script: "exit 0"},
+		"failure":           {script: "exit 3", expected: 3},
+		"killed by SIGTERM": {script: "kill -TERM $$", expected: 143},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			runner, err := genv.NewCommandRunner(genv.CommandRunnerConfig{
+				Name: "sh",
+				Args: []string{"-c", tt.script},
+			})
+			require.NoError(t, err)
+
+			err = runner.Run()
+			if tt.expected == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var exitErr *genv.ExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, tt.expected, exitErr.Code)
+		})
+	}
+}
+
+func TestCommandRunner_Run_Stdin(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+
+	runner, err := genv.NewCommandRunner(genv.CommandRunnerConfig{
+		Name:   "cat",
+		Stdin:  strings.NewReader("from stdin"),
+		Stdout: stdout,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, runner.Run())
+	assert.Equal(t, "from stdin", stdout.String())
+}
+
+func TestCommandRunner_Run_Env(t *testing.T) {
+	t.Setenv("GENV_TEST_SHARED", "host")
+	t.Setenv("GENV_TEST_HOST_ONLY", "host")
+	t.Setenv("GENV_TEST_LC_ALL", "C")
+
+	testCases := map[string]struct {
+		cfg      genv.CommandRunnerConfig
+		expected string
+		errMsg   string
+	}{
+		"inherit everything, dotenv takes precedence": {
+			expected: "SHARED=dotenv HOST_ONLY=host LC_ALL=C DOTENV_ONLY=dotenv\n",
+		},
+		"inherit everything, host takes precedence": {
+			cfg:      genv.CommandRunnerConfig{Precedence: genv.EnvPrecedenceHost},
+			expected: "SHARED=host HOST_ONLY=host LC_ALL=C DOTENV_ONLY=dotenv\n",
+		},
+		"clean env": {
+			cfg:      genv.CommandRunnerConfig{CleanEnv: true},
+			expected: "SHARED=dotenv HOST_ONLY= LC_ALL= DOTENV_ONLY=dotenv\n",
+		},
+		"clean env with allowlist": {
+			cfg:      genv.CommandRunnerConfig{CleanEnv: true, Inherit: []string{"GENV_TEST_HOST_ONLY", "GENV_TEST_LC_*"}},
+			expected: "SHARED=dotenv HOST_ONLY=host LC_ALL=C DOTENV_ONLY=dotenv\n",
+		},
+		"clean env with allowlist, host takes precedence": {
+			cfg:      genv.CommandRunnerConfig{CleanEnv: true, Inherit: []string{"GENV_TEST_SHARED"}, Precedence: genv.EnvPrecedenceHost},
+			expected: "SHARED=host HOST_ONLY= LC_ALL= DOTENV_ONLY=dotenv\n",
+		},
+		"allowlist without clean env": {
+			cfg:    genv.CommandRunnerConfig{Inherit: []string{"PATH"}},
+			errMsg: "inherit can only be used with clean env",
+		},
+		"unsupported precedence": {
+			cfg:    genv.CommandRunnerConfig{Precedence: "random"},
+			errMsg: "unsupported env precedence: random",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+
+			cfg := tt.cfg
+			cfg.Name = "sh"
+			cfg.Args = []string{"-c", `echo "SHARED=$GENV_TEST_SHARED HOST_ONLY=$GENV_TEST_HOST_ONLY LC_ALL=$GENV_TEST_LC_ALL DOTENV_ONLY=$GENV_TEST_DOTENV_ONLY"`}
+			cfg.Envs = map[string]string{
+				"GENV_TEST_SHARED":      "dotenv",
+				"GENV_TEST_DOTENV_ONLY": "dotenv",
+			}
+			cfg.Stdout = stdout
+
+			runner, err := genv.NewCommandRunner(cfg)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			require.NoError(t, runner.Run())
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,60 @@
+//go:build !windows
+
+package genv
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"syscall"
+)
+
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// terminalSignals are the signals the terminal sends to its foreground
+// process group, which the command is in when it shares the process group of
+// genv.
+var terminalSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGHUP,
+}
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func forwardSignal(p *os.Process, sig os.Signal, group bool) error {
+	if !group {
+		// The command has already received the signals from the terminal,
+		// and would see them twice, e.g. take one Ctrl+C for two.
+		if slices.Contains(terminalSignals, sig) {
+			return nil
+		}
+
+		return p.Signal(sig)
+	}
+
+	// The command is the leader of its process group, so the group ID is
+	// the PID of the command.
+	return syscall.Kill(-p.Pid, sig.(syscall.Signal))
+}
+
+func exitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return err.ExitCode()
+}
+
+func execProcess(path string, args []string, env []string) error {
+	return syscall.Exec(path, args, env)
+}
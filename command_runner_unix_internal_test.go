@@ -0,0 +1,44 @@
+//go:build !windows
+
+package genv
+
+import (
+	"bufio"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardSignal_SharedProcessGroup(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh", "-c", `trap "echo INT" INT
+trap "echo TERM; exit 3" TERM
+echo ready
+while :; do sleep 0.1; done`)
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	defer cmd.Process.Kill()
+
+	r := bufio.NewReader(stdout)
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "ready\n", line)
+
+	// The command shares the foreground process group with genv, so it has
+	// already received the signals from the terminal.
+	require.NoError(t, forwardSignal(cmd.Process, syscall.SIGINT, false))
+	require.NoError(t, forwardSignal(cmd.Process, syscall.SIGTERM, false))
+
+	line, err = r.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "TERM\n", line)
+
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, cmd.Wait(), &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode())
+}
@@ -0,0 +1,92 @@
+//go:build !windows
+
+package genv_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mrtc0/genv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Not parallel: the test sends SIGTERM to the test process itself.
+func TestCommandRunner_Run_ForwardSignal(t *testing.T) {
+	stdout, w := io.Pipe()
+
+	// The signal is sent to the whole process group, so the child of the
+	// command receives it too. The command itself waits for the child and
+	// exits with its status.
+	script := `trap : TERM
+sh -c 'trap "exit 42" TERM; echo ready; while :; do sleep 0.1; done' &
+pid=$!
+wait $pid
+wait $pid`
+
+	runner, err := genv.NewCommandRunner(genv.CommandRunnerConfig{
+		Name:   "sh",
+		Args:   []string{"-c", script},
+		Stdout: w,
+	})
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- runner.Run()
+		w.Close()
+	}()
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "ready\n", line)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	select {
+	case err := <-errCh:
+		var exitErr *genv.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 42, exitErr.Code)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the signal was not forwarded to the command")
+	}
+}
+
+// execTestEnv makes the test binary replace itself with the command in
+// TestCommandRunner_Exec.
+const execTestEnv = "GENV_TEST_EXEC"
+
+func TestCommandRunner_Exec(t *testing.T) {
+	if os.Getenv(execTestEnv) != "" {
+		runner, err := genv.NewCommandRunner(genv.CommandRunnerConfig{
+			Name: "sh",
+			Args: []string{"-c", `echo "$$ $GREETING"; exit 7`},
+			Envs: map[string]string{"GREETING": "hello"},
+		})
+		if err == nil {
+			err = runner.Exec()
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	t.Parallel()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCommandRunner_Exec$")
+	cmd.Env = append(os.Environ(), execTestEnv+"=1")
+	out, err := cmd.Output()
+
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 7, exitErr.ExitCode(), string(exitErr.Stderr))
+	// The command runs in the process of genv
+	assert.Equal(t, strconv.Itoa(cmd.Process.Pid)+" hello\n", string(out))
+}
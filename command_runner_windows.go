@@ -0,0 +1,26 @@
+package genv
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Console control events such as Ctrl+C are delivered to every process
+// attached to the console, so genv only has to survive them while the
+// command handles them.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func forwardSignal(p *os.Process, sig os.Signal, group bool) error {
+	return nil
+}
+
+func exitCode(err *exec.ExitError) int {
+	return err.ExitCode()
+}
+
+func execProcess(path string, args []string, env []string) error {
+	return errors.New("exec mode is not supported on Windows")
+}
@@ -1,117 +1,309 @@
 package genv
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"maps"
+	"reflect"
+	"time"
 
-	"github.com/mrtc0/genv/provider/onepassword"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
+	// The maximum number of secrets fetched concurrently.
+	// If omitted, defaults to DefaultConcurrency.
+	Concurrency    int                 `yaml:"concurrency,omitempty"`
 	SecretProvider SecretProvider      `yaml:"secretProvider,omitempty"`
 	Envs           map[string]EnvValue `yaml:"envs,omitempty"`
-}
+	// EnvFrom expands JSON secrets into envs. Envs defined in Envs take
+	// precedence, and later sources take precedence over earlier ones.
+	EnvFrom []EnvFromSource `yaml:"envFrom,omitempty"`
+	// Profiles holds the overrides for each profile. The profile selected
+	// with WithProfile is deep-merged into the config when it is loaded.
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+	// Include lists the config files merged into this config. Paths are
+	// relative to the directory of this config file.
+	Include []string `yaml:"include,omitempty"`
+	// Kubernetes holds the metadata of the manifests generated with the
+	// k8s-secret format.
+	Kubernetes KubernetesConfig `yaml:"kubernetes,omitempty"`
 
-type SecretProvider struct {
-	Aws         []AwsProvider         `yaml:"aws,omitempty"`
-	GoogleCloud []GoogleCloudProvider `yaml:"googleCloud,omitempty"`
-	OnePassword []OnePasswordProvider `yaml:"1password,omitempty"`
-	Exec        []ExecProvider        `yaml:"exec,omitempty"`
+	// sources records where the envs and providers are defined. It is only
+	// set when the config is loaded with LoadConfig.
+	sources *configSources
+	// filter is the filter the config was narrowed down with by Filter, and
+	// envFromIndex maps the index of each EnvFrom source to its index in
+	// the config before filtering.
+	filter       *EnvFilter
+	envFromIndex []int
 }
 
-type AwsProvider struct {
-	ID      string  `yaml:"id"`
-	Service string  `yaml:"service"`
-	Region  string  `yaml:"region,omitempty"`
-	Auth    AwsAuth `yaml:"auth,omitempty"`
+// Profile represents the overrides applied to the config when the profile
+// is selected. Providers are merged with the providers of the same ID, and
+// envs replace the envs of the same name.
+type Profile struct {
+	Concurrency    int                 `yaml:"concurrency,omitempty"`
+	SecretProvider SecretProvider      `yaml:"secretProvider,omitempty"`
+	Envs           map[string]EnvValue `yaml:"envs,omitempty"`
+	EnvFrom        []EnvFromSource     `yaml:"envFrom,omitempty"`
+	Kubernetes     KubernetesConfig    `yaml:"kubernetes,omitempty"`
 }
 
-type AwsAuth struct {
-	Profile                string   `yaml:"profile,omitempty"`
-	SharedCredentialsFiles []string `yaml:"sharedCredentialsFiles,omitempty"`
-	SharedConfigFiles      []string `yaml:"sharedConfigFiles,omitempty"`
+// KubernetesConfig represents the metadata of the Kubernetes manifests
+// generated from the envs.
+type KubernetesConfig struct {
+	// The name of the Secret
+	Name      string            `yaml:"name,omitempty"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+	// The name of the ConfigMap to put the envs with plain values in.
+	// If omitted, all envs are put in the Secret.
+	ConfigMap string `yaml:"configMap,omitempty"`
 }
 
-type GoogleCloudProvider struct {
-	ID        string `yaml:"id"`
-	Service   string `yaml:"service"`
-	ProjectID string `yaml:"projectID"`
-	Location  string `yaml:"location,omitempty"`
+// SecretProvider holds the secret providers, configured as a list under the
+// name of their ProviderType.
+type SecretProvider struct {
+	// configs holds the configurations of the providers by the name of
+	// their type.
+	configs map[string][]ProviderConfig
+	// sources records where each provider is defined, by provider ID.
+	sources map[string][]Source
 }
 
-type OnePasswordProvider struct {
-	ID   string          `yaml:"id"`
-	Auth OnePasswordAuth `yaml:"auth,omitempty"`
-}
+// NewSecretProvider returns the providers with the configurations, by the
+// name of their type, to build a Config without loading a file.
+func NewSecretProvider(configs map[string][]ProviderConfig) SecretProvider {
+	var sp SecretProvider
+	for kind, c := range configs {
+		sp.add(kind, c...)
+	}
 
-// OnePasswordAuth represents the authentication configuration for 1Password
-type OnePasswordAuth struct {
-	// The authentication method to use for 1Password
-	// Possible values are "cli" and "service-account"
-	// If omitted, defaults to "cli"
-	Method onepassword.OnePasswordAuthMethod `yaml:"method"`
-	// The account to use for 1Password (only applicable when Method is CLI)
-	Account string `yaml:"account,omitempty"`
+	return sp
 }
 
-// ExecCommand supports two YAML forms for specifying a command:
-//
-//	String form:   command: "vault kv get -format=json secret/myapp | jq .data"
-//	Sequence form: command: ["vault", "kv", "get", "-format=json", "secret/myapp"]
-//
-// The string form is passed to "sh -c" so that shell features such as pipes
-// and redirections work. The sequence form is executed directly via execve,
-// which avoids shell interpretation and is safer when the arguments are known
-// at configuration time.
-type ExecCommand struct {
-	Args []string
+// Configs returns the configurations of the providers by the name of their
+// type.
+func (sp SecretProvider) Configs() map[string][]ProviderConfig {
+	return maps.Clone(sp.configs)
 }
 
-// UnmarshalYAML allows ExecCommand to accept either a plain string or a
-// sequence of strings in YAML.
-//
-//   - Scalar (string): wrapped as ["sh", "-c", <value>] so the shell handles
-//     pipes, redirections, etc.
-//   - Sequence: decoded as-is and executed directly without a shell.
-func (c *ExecCommand) UnmarshalYAML(value *yaml.Node) error {
-	switch value.Kind {
-	case yaml.ScalarNode:
-		c.Args = []string{"sh", "-c", value.Value}
-	case yaml.SequenceNode:
-		return value.Decode(&c.Args)
-	default:
-		return fmt.Errorf("command must be a string or sequence")
-	}
-	return nil
+// UnmarshalYAML decodes the providers with the decoders of their types.
+func (sp *SecretProvider) UnmarshalYAML(node *yaml.Node) error {
+	return sp.decodeProviders(node)
 }
 
-type ExecProvider struct {
-	ID      string      `yaml:"id"`
-	Command ExecCommand `yaml:"command"`
+// ProviderSettings represents the settings shared by all secret providers.
+// It is inlined into each provider configuration.
+type ProviderSettings struct {
+	// The maximum number of concurrent requests to the provider.
+	// If omitted, only the global limit applies.
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// The maximum time each request to the provider may take, e.g. "10s".
+	// If omitted, only the --timeout option applies.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// The number of times a request that failed with a transient error,
+	// such as throttling, a server error or a timeout, is retried.
+	// If omitted, defaults to DefaultRetries. Unless it is 0, it replaces
+	// the retries of the AWS and Google Cloud SDKs.
+	Retries *int `yaml:"retries,omitempty"`
+	// The delay before the first retry, e.g. "500ms". The delay doubles
+	// with each retry, up to MaxBackoff. If omitted, defaults to
+	// DefaultBackoff.
+	Backoff time.Duration `yaml:"backoff,omitempty"`
 }
 
 type EnvValue struct {
 	Value     string     `yaml:"value,omitempty"`
 	SecretRef *SecretRef `yaml:"secretRef,omitempty"`
+	// A text/template that is rendered into the value. The values of other
+	// envs are available as .Env.NAME, and secrets can be retrieved with
+	// the secret function: {{ secret "provider-id" "key" ".property" }}
+	Template string `yaml:"template,omitempty"`
+	// The tags used to select the env with --tag
+	Tags []string `yaml:"tags,omitempty"`
 }
 
 type SecretRef struct {
-	Provider string `yaml:"provider,omitempty"`
-	Key      string `yaml:"key,omitempty"`
+	Provider string `yaml:"provider,omitempty" jsonschema:"required"`
+	Key      string `yaml:"key,omitempty" jsonschema:"required"`
 	Property string `yaml:"property,omitempty"`
 }
 
-func LoadConfig(filePath string) (*Config, error) {
-	f, err := os.ReadFile(filePath)
+// EnvFromSource represents a JSON secret whose top-level fields are each
+// expanded into an env.
+type EnvFromSource struct {
+	// The secret to expand. If Property is set, the JSON object at the
+	// property is expanded instead of the whole secret.
+	SecretRef *SecretRef `yaml:"secretRef" jsonschema:"required"`
+	// The prefix added to the env names
+	Prefix string `yaml:"prefix,omitempty"`
+	// The case transformation applied to the field names
+	// Possible values are "upper" and "lower"
+	// If omitted, the field names are used as they are
+	KeyCase KeyCase `yaml:"keyCase,omitempty"`
+	// The fields to expand. If omitted, all fields are expanded.
+	Include []string `yaml:"include,omitempty"`
+	// The fields not to expand
+	Exclude []string `yaml:"exclude,omitempty"`
+	// The tags used to select the expanded envs with --tag
+	Tags []string `yaml:"tags,omitempty"`
+}
+
+type KeyCase string
+
+const (
+	KeyCaseUpper KeyCase = "upper"
+	KeyCaseLower KeyCase = "lower"
+)
+
+type loadOptions struct {
+	profile string
+}
+
+// LoadOption configures how LoadConfig loads the config.
+type LoadOption func(*loadOptions)
+
+// WithProfile selects the profile merged into the config.
+// An empty name selects no profile.
+func WithProfile(name string) LoadOption {
+	return func(o *loadOptions) {
+		o.profile = name
+	}
+}
+
+// LoadConfig loads the config file. The config is deep-merged from the
+// following sources, in order of increasing precedence:
+//
+//  1. The files listed in include, in order (recursively)
+//  2. The config file itself
+//  3. The profile selected with WithProfile
+//  4. The local override file next to the config file (see LocalConfigPath),
+//     including its own includes and the selected profile, if it exists
+//
+// See mergeNode for how the sources are merged.
+func LoadConfig(filePath string, opts ...LoadOption) (*Config, error) {
+	o := &loadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	l := newConfigLoader()
+
+	root, err := l.load(filePath, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(f, &config); err != nil {
+	local, err := l.loadOptional(LocalConfigPath(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	if o.profile != "" {
+		found, err := l.applyProfile(root, o.profile)
+		if err != nil {
+			return nil, err
+		}
+
+		if local != nil {
+			foundLocal, err := l.applyProfile(local, o.profile)
+			if err != nil {
+				return nil, err
+			}
+			found = found || foundLocal
+		}
+
+		if !found {
+			return nil, fmt.Errorf("profile %q is not defined", o.profile)
+		}
+	}
+
+	if local != nil {
+		root = mergeNode(root, local)
+	}
+
+	if err := l.resolvePluginPaths(root); err != nil {
 		return nil, err
 	}
 
+	if errs := l.checkFields(root, reflect.TypeFor[Config](), ""); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var config Config
+	if err := root.Decode(&config); err != nil {
+		return nil, l.locate(err)
+	}
+
+	sources := l.collectSources(root)
+	config.sources = sources
+	config.SecretProvider.sources = sources.providers
+
 	return &config, nil
 }
+
+// IsSecretEnv reports whether the value of the env may come from a secret
+// provider, that is, whether it is not defined with a plain value in Envs.
+func (c *Config) IsSecretEnv(name string) bool {
+	env, ok := c.Envs[name]
+	if !ok {
+		// Expanded from envFrom
+		return true
+	}
+
+	return env.SecretRef != nil || env.Template != ""
+}
+
+func (c *Config) envSources(name string) []Source {
+	if c.sources == nil {
+		return nil
+	}
+
+	if source, ok := c.sources.envs[name]; ok {
+		return []Source{source}
+	}
+
+	return nil
+}
+
+func (c *Config) envFromSources(i int) []Source {
+	i = c.originalEnvFromIndex(i)
+	if c.sources == nil || i >= len(c.sources.envFrom) {
+		return nil
+	}
+
+	return []Source{c.sources.envFrom[i]}
+}
+
+// envSource returns where the env is defined, or the zero Source if unknown.
+func (c *Config) envSource(name string) Source {
+	if sources := c.envSources(name); len(sources) > 0 {
+		return sources[0]
+	}
+
+	return Source{}
+}
+
+func (c *Config) envFromSource(i int) Source {
+	if sources := c.envFromSources(i); len(sources) > 0 {
+		return sources[0]
+	}
+
+	return Source{}
+}
+
+// providerItemSource returns where the i-th provider of the type is defined,
+// or the zero Source if unknown.
+func (c *Config) providerItemSource(kind string, i int) Source {
+	if c.sources == nil || i >= len(c.sources.providerItems[kind]) {
+		return Source{}
+	}
+
+	return c.sources.providerItems[kind][i]
+}
+
+func (sp SecretProvider) providerSources(id string) []Source {
+	return sp.sources[id]
+}
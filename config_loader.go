@@ -0,0 +1,265 @@
+package genv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source represents the location in a config file where a part of the
+// config is defined.
+type Source struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (s Source) String() string {
+	return fmt.Sprintf("%s:%d:%d", s.File, s.Line, s.Column)
+}
+
+// nodeError is an error in the part of the config at the node. LoadConfig
+// reports it at the position of the node in the config files.
+type nodeError struct {
+	node *yaml.Node
+	err  error
+}
+
+func (e *nodeError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.node.Line, e.err)
+}
+
+func (e *nodeError) Unwrap() error {
+	return e.err
+}
+
+// describeSources returns a suffix for error messages that tells where
+// something is defined, or an empty string if it is unknown.
+func describeSources(sources ...Source) string {
+	if len(sources) == 0 {
+		return ""
+	}
+
+	s := make([]string, 0, len(sources))
+	for _, source := range sources {
+		s = append(s, source.String())
+	}
+
+	return " (defined in " + strings.Join(s, ", ") + ")"
+}
+
+// LocalConfigPath returns the path of the local override file of the config
+// file, e.g. ".genv.local.yaml" for ".genv.yaml".
+func LocalConfigPath(filePath string) string {
+	ext := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, ext) + ".local" + ext
+}
+
+// configLoader loads config files and their includes into a single YAML
+// node, remembering which file each node came from.
+type configLoader struct {
+	files map[*yaml.Node]string
+}
+
+func newConfigLoader() *configLoader {
+	return &configLoader{files: make(map[*yaml.Node]string)}
+}
+
+// load reads the config file and merges the files it includes. The included
+// files are merged in order, and the including file takes precedence over
+// them. Paths are relative to the directory of the including file.
+func (l *configLoader) load(filePath string, stack []string) (*yaml.Node, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(stack, abs) {
+		return nil, fmt.Errorf("include cycle detected: %s", strings.Join(append(stack, abs), " -> "))
+	}
+
+	f, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(f, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(doc.Content) > 0 && !isNull(doc.Content[0]) {
+		root = doc.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d:%d: config must be a mapping", filePath, root.Line, root.Column)
+	}
+	l.record(root, filePath)
+
+	includeNode := mappingValue(root, "include")
+	if includeNode == nil || isNull(includeNode) {
+		return root, nil
+	}
+
+	var includes []string
+	if err := includeNode.Decode(&includes); err != nil {
+		return nil, fmt.Errorf("%s:%d:%d: include must be a list of file paths", filePath, includeNode.Line, includeNode.Column)
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(filePath), include)
+		}
+
+		included, err := l.load(include, append(stack, abs))
+		if err != nil {
+			return nil, fmt.Errorf("failed to include %s from %s: %w", include, filePath, err)
+		}
+
+		merged = mergeNode(merged, included)
+	}
+
+	return mergeNode(merged, root), nil
+}
+
+// loadOptional loads the config file if it exists, or returns nil.
+func (l *configLoader) loadOptional(filePath string) (*yaml.Node, error) {
+	root, err := l.load(filePath, nil)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	return root, err
+}
+
+// applyProfile merges the profile into root. It returns false if root does
+// not define the profile.
+func (l *configLoader) applyProfile(root *yaml.Node, name string) (bool, error) {
+	profile := mappingValue(mappingValue(root, "profiles"), name)
+	if profile == nil {
+		return false, nil
+	}
+
+	switch {
+	case profile.Kind == yaml.MappingNode:
+		// Merge a copy so that the profiles in the config are kept as written.
+		mergeNode(root, l.copy(profile))
+	case isNull(profile):
+	default:
+		return false, fmt.Errorf("%s: profile %q must be a mapping", l.source(profile), name)
+	}
+
+	return true, nil
+}
+
+func (l *configLoader) record(node *yaml.Node, file string) {
+	l.files[node] = file
+	for _, child := range node.Content {
+		l.record(child, file)
+	}
+}
+
+// copy returns a deep copy of the node, keeping track of the file the
+// copied nodes came from.
+func (l *configLoader) copy(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = l.copy(child)
+	}
+	l.files[&c] = l.files[node]
+
+	return &c
+}
+
+// locate turns the nodeErrors among the joined errors into ValidationErrors
+// with the position of the node.
+func (l *configLoader) locate(err error) error {
+	errs := unjoin(err)
+	for i, err := range errs {
+		if e, ok := err.(*nodeError); ok {
+			errs[i] = &ValidationError{Source: l.source(e.node), Message: e.err.Error()}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (l *configLoader) source(node *yaml.Node) Source {
+	return Source{File: l.files[node], Line: node.Line, Column: node.Column}
+}
+
+// sources returns the distinct files the node and its descendants came
+// from, each with the position of the first node from the file.
+func (l *configLoader) sources(node *yaml.Node) []Source {
+	var sources []Source
+
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		file := l.files[n]
+		if !slices.ContainsFunc(sources, func(s Source) bool { return s.File == file }) {
+			sources = append(sources, l.source(n))
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	return sources
+}
+
+// configSources records where the envs and providers of a config are defined.
+type configSources struct {
+	envs      map[string]Source
+	envFrom   []Source
+	providers map[string][]Source
+	// providerItems holds the position of each provider by type (the YAML
+	// key under secretProvider) and index.
+	providerItems map[string][]Source
+}
+
+func (l *configLoader) collectSources(root *yaml.Node) *configSources {
+	s := &configSources{
+		envs:          make(map[string]Source),
+		providers:     make(map[string][]Source),
+		providerItems: make(map[string][]Source),
+	}
+
+	if envs := mappingValue(root, "envs"); envs != nil && envs.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(envs.Content); i += 2 {
+			s.envs[envs.Content[i].Value] = l.source(envs.Content[i+1])
+		}
+	}
+
+	if envFrom := mappingValue(root, "envFrom"); envFrom != nil && envFrom.Kind == yaml.SequenceNode {
+		for _, item := range envFrom.Content {
+			s.envFrom = append(s.envFrom, l.source(item))
+		}
+	}
+
+	if sp := mappingValue(root, "secretProvider"); sp != nil && sp.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(sp.Content); i += 2 {
+			kind := sp.Content[i].Value
+			for _, item := range sp.Content[i+1].Content {
+				s.providerItems[kind] = append(s.providerItems[kind], l.source(item))
+				if id := mappingValue(item, "id"); id != nil {
+					s.providers[id.Value] = append(s.providers[id.Value], l.sources(item)...)
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
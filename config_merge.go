@@ -0,0 +1,103 @@
+package genv
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// mergeNode deep-merges the YAML node src into dst and returns the merged
+// node. Values in src take precedence over values in dst:
+//
+//   - Mappings are merged key by key.
+//   - Sequences whose items are all mappings with an "id" key (such as the
+//     lists of secret providers) are merged item by item, matching items by
+//     id. Items with a new id are appended.
+//   - Each entry of "envs" is replaced as a whole, so that an env defined
+//     with secretRef can be overridden with value and vice versa.
+//   - Any other value, including other sequences, is replaced.
+//
+// Nodes taken from src are reused rather than copied, so that the file each
+// node came from can still be looked up after merging.
+func mergeNode(dst, src *yaml.Node) *yaml.Node {
+	return mergeNodeAt(dst, src, nil)
+}
+
+func mergeNodeAt(dst, src *yaml.Node, path []string) *yaml.Node {
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode && !isEnvEntry(path):
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			key, value := src.Content[i], src.Content[i+1]
+
+			if j := mappingIndex(dst, key.Value); j >= 0 {
+				dst.Content[j] = mergeNodeAt(dst.Content[j], value, append(path, key.Value))
+				continue
+			}
+
+			dst.Content = append(dst.Content, key, value)
+		}
+		return dst
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode && hasIDs(dst) && hasIDs(src):
+		for _, item := range src.Content {
+			id := mappingValue(item, "id").Value
+
+			merged := false
+			for j, existing := range dst.Content {
+				if mappingValue(existing, "id").Value == id {
+					dst.Content[j] = mergeNodeAt(existing, item, append(path, id))
+					merged = true
+					break
+				}
+			}
+
+			if !merged {
+				dst.Content = append(dst.Content, item)
+			}
+		}
+		return dst
+	default:
+		return src
+	}
+}
+
+func isEnvEntry(path []string) bool {
+	return len(path) == 2 && path[0] == "envs"
+}
+
+func hasIDs(seq *yaml.Node) bool {
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			return false
+		}
+
+		if id := mappingValue(item, "id"); id == nil || id.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mappingValue returns the value of key in the mapping node, or nil if the
+// key does not exist.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	if i := mappingIndex(node, key); i >= 0 {
+		return node.Content[i]
+	}
+
+	return nil
+}
+
+// mappingIndex returns the index of the value of key in the content of the
+// mapping node, or -1 if the key does not exist.
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i + 1
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,392 @@
+package genv_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mrtc0/genv"
+	"github.com/mrtc0/genv/provider/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+// assertConfig compares the configs, ignoring the profiles which are kept as
+// written and the positions of the definitions.
+func assertConfig(t *testing.T, expected, actual *genv.Config) {
+	t.Helper()
+
+	opts := []cmp.Option{
+		cmpopts.IgnoreUnexported(genv.Config{}),
+		cmp.Transformer("Configs", genv.SecretProvider.Configs),
+		cmpopts.IgnoreFields(genv.Config{}, "Profiles", "Include"),
+	}
+	if diff := cmp.Diff(actual, expected, opts...); diff != "" {
+		assert.Fail(t, "unexpected config", "(- got, + expected)\n%s", diff)
+	}
+}
+
+func TestLoadConfig_Profile(t *testing.T) {
+	t.Parallel()
+
+	content := `
+secretProvider:
+  aws:
+    - id: aws
+      service: SecretsManager
+      region: us-east-1
+      auth:
+        profile: default
+  googleCloud:
+    - id: gcp
+      service: SecretManager
+      projectID: dev-project
+
+envs:
+  APP_ENV:
+    value: development
+  DB_PASSWORD:
+    secretRef:
+      provider: aws
+      key: db-credentials
+      property: password
+
+profiles:
+  prod:
+    secretProvider:
+      aws:
+        - id: aws
+          region: ap-northeast-1
+          auth:
+            profile: prod
+        - id: aws-audit
+          service: ParameterStore
+      googleCloud:
+        - id: gcp
+          projectID: prod-project
+    envs:
+      APP_ENV:
+        value: production
+      DB_PASSWORD:
+        value: overridden
+      AUDIT_TOKEN:
+        secretRef:
+          provider: aws-audit
+          key: /audit/token
+  empty:
+`
+
+	testCases := map[string]struct {
+		profile  string
+		expected *genv.Config
+		errMsg   string
+	}{
+		"without profile": {
+			expected: &genv.Config{
+				SecretProvider: genv.NewSecretProvider(map[string][]genv.ProviderConfig{
+					"aws": {
+						genv.AwsProvider{ID: "aws", Service: aws.AWSSecretsManager, Region: "us-east-1", Auth: genv.AwsAuth{Profile: "default"}},
+					},
+					"googleCloud": {
+						genv.GoogleCloudProvider{ID: "gcp", Service: "SecretManager", ProjectID: "dev-project"},
+					},
+				}),
+				Envs: map[string]genv.EnvValue{
+					"APP_ENV":     {Value: "development"},
+					"DB_PASSWORD": {SecretRef: &genv.SecretRef{Provider: "aws", Key: "db-credentials", Property: "password"}},
+				},
+			},
+		},
+		"with profile": {
+			profile: "prod",
+			expected: &genv.Config{
+				SecretProvider: genv.NewSecretProvider(map[string][]genv.ProviderConfig{
+					"aws": {
+						genv.AwsProvider{ID: "aws", Service: aws.AWSSecretsManager, Region: "ap-northeast-1", Auth: genv.AwsAuth{Profile: "prod"}},
+						genv.AwsProvider{ID: "aws-audit", Service: aws.SSMParameterStore},
+					},
+					"googleCloud": {
+						genv.GoogleCloudProvider{ID: "gcp", Service: "SecretManager", ProjectID: "prod-project"},
+					},
+				}),
+				Envs: map[string]genv.EnvValue{
+					"APP_ENV":     {Value: "production"},
+					"DB_PASSWORD": {Value: "overridden"},
+					"AUDIT_TOKEN": {SecretRef: &genv.SecretRef{Provider: "aws-audit", Key: "/audit/token"}},
+				},
+			},
+		},
+		"with empty profile": {
+			profile: "empty",
+			expected: &genv.Config{
+				SecretProvider: genv.NewSecretProvider(map[string][]genv.ProviderConfig{
+					"aws": {
+						genv.AwsProvider{ID: "aws", Service: aws.AWSSecretsManager, Region: "us-east-1", Auth: genv.AwsAuth{Profile: "default"}},
+					},
+					"googleCloud": {
+						genv.GoogleCloudProvider{ID: "gcp", Service: "SecretManager", ProjectID: "dev-project"},
+					},
+				}),
+				Envs: map[string]genv.EnvValue{
+					"APP_ENV":     {Value: "development"},
+					"DB_PASSWORD": {SecretRef: &genv.SecretRef{Provider: "aws", Key: "db-credentials", Property: "password"}},
+				},
+			},
+		},
+		"undefined profile": {
+			profile: "staging",
+			errMsg:  `profile "staging" is not defined`,
+		},
+	}
+
+	path := writeConfigFile(t, t.TempDir(), ".genv.yaml", content)
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := genv.LoadConfig(path, genv.WithProfile(tt.profile))
+			if tt.errMsg != "" {
+				require.EqualError(t, err, tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+
+			assertConfig(t, tt.expected, cfg)
+		})
+	}
+}
+
+func TestLoadConfig_IncludeAndLocal(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "shared"), 0700))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "service"), 0700))
+
+	writeConfigFile(t, filepath.Join(dir, "shared"), "providers.yaml", `
+secretProvider:
+  aws:
+    - id: aws
+      service: SecretsManager
+      region: us-east-1
+      auth:
+        profile: shared
+envs:
+  LOG_LEVEL:
+    value: info
+  APP_ENV:
+    value: shared
+`)
+	writeConfigFile(t, filepath.Join(dir, "shared"), "base.yaml", `
+envs:
+  LOG_LEVEL:
+    value: debug
+  REGION:
+    value: us-east-1
+`)
+	path := writeConfigFile(t, filepath.Join(dir, "service"), ".genv.yaml", `
+include:
+  - ../shared/base.yaml
+  - ../shared/providers.yaml
+envs:
+  APP_ENV:
+    value: development
+  DB_PASSWORD:
+    secretRef:
+      provider: aws
+      key: db-credentials
+profiles:
+  prod:
+    secretProvider:
+      aws:
+        - id: aws
+          region: ap-northeast-1
+          auth:
+            profile: prod
+    envs:
+      APP_ENV:
+        value: production
+`)
+	writeConfigFile(t, filepath.Join(dir, "service"), ".genv.local.yaml", `
+secretProvider:
+  aws:
+    - id: aws
+      auth:
+        profile: my-own-profile
+`)
+
+	testCases := map[string]struct {
+		profile  string
+		expected *genv.Config
+	}{
+		"without profile": {
+			expected: &genv.Config{
+				SecretProvider: genv.NewSecretProvider(map[string][]genv.ProviderConfig{
+					"aws": {
+						genv.AwsProvider{ID: "aws", Service: aws.AWSSecretsManager, Region: "us-east-1", Auth: genv.AwsAuth{Profile: "my-own-profile"}},
+					},
+				}),
+				Envs: map[string]genv.EnvValue{
+					// providers.yaml is included after base.yaml and takes precedence
+					"LOG_LEVEL": {Value: "info"},
+					"REGION":    {Value: "us-east-1"},
+					// The including file takes precedence over the included files
+					"APP_ENV":     {Value: "development"},
+					"DB_PASSWORD": {SecretRef: &genv.SecretRef{Provider: "aws", Key: "db-credentials"}},
+				},
+			},
+		},
+		"local overrides take precedence over profile": {
+			profile: "prod",
+			expected: &genv.Config{
+				SecretProvider: genv.NewSecretProvider(map[string][]genv.ProviderConfig{
+					"aws": {
+						genv.AwsProvider{ID: "aws", Service: aws.AWSSecretsManager, Region: "ap-northeast-1", Auth: genv.AwsAuth{Profile: "my-own-profile"}},
+					},
+				}),
+				Envs: map[string]genv.EnvValue{
+					"LOG_LEVEL":   {Value: "info"},
+					"REGION":      {Value: "us-east-1"},
+					"APP_ENV":     {Value: "production"},
+					"DB_PASSWORD": {SecretRef: &genv.SecretRef{Provider: "aws", Key: "db-credentials"}},
+				},
+			},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := genv.LoadConfig(path, genv.WithProfile(tt.profile))
+			require.NoError(t, err)
+			assertConfig(t, tt.expected, cfg)
+		})
+	}
+}
+
+func TestLoadConfig_IncludeCycle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "a.yaml", "include: [b.yaml]\n")
+	writeConfigFile(t, dir, "b.yaml", "include: [a.yaml]\n")
+
+	_, err := genv.LoadConfig(filepath.Join(dir, "a.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle detected")
+}
+
+func TestLoadConfig_PluginPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "shared"), 0700))
+	absolute := filepath.Join(dir, "genv-provider-absolute")
+
+	writeConfigFile(t, filepath.Join(dir, "shared"), "plugins.yaml", `
+secretProvider:
+  plugin:
+    - id: shared
+      path: ./bin/genv-provider-shared
+    - id: named
+      name: named
+`)
+	path := writeConfigFile(t, dir, ".genv.yaml", `
+include: [shared/plugins.yaml]
+secretProvider:
+  plugin:
+    - id: local
+      path: bin/genv-provider-local
+    - id: absolute
+      path: `+absolute+`
+`)
+
+	// Load the config with a path relative to the working directory, which
+	// the plugin paths must not depend on.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	relative, err := filepath.Rel(wd, path)
+	require.NoError(t, err)
+	cfg, err := genv.LoadConfig(relative)
+	require.NoError(t, err)
+
+	assert.Equal(t, []genv.ProviderConfig{
+		genv.PluginProvider{ID: "shared", Path: filepath.Join(dir, "shared", "bin", "genv-provider-shared")},
+		genv.PluginProvider{ID: "named", Name: "named"},
+		genv.PluginProvider{ID: "local", Path: filepath.Join(dir, "bin", "genv-provider-local")},
+		genv.PluginProvider{ID: "absolute", Path: absolute},
+	}, cfg.SecretProvider.Configs()["plugin"])
+}
+
+func TestLoadConfig_SourcesInErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	shared := writeConfigFile(t, dir, "shared.yaml", `secretProvider:
+  exec:
+    - id: tool
+      command: []
+`)
+	path := writeConfigFile(t, dir, ".genv.yaml", `include: [shared.yaml]
+envs:
+  API_KEY:
+    secretRef:
+      provider: undefined
+      key: api-key
+`)
+	local := writeConfigFile(t, dir, ".genv.local.yaml", `secretProvider:
+  exec:
+    - id: tool
+      concurrency: 1
+`)
+
+	cfg, err := genv.LoadConfig(path)
+	require.NoError(t, err)
+
+	svc, err := genv.NewSecretProviderService(context.Background(), cfg.SecretProvider)
+	require.NoError(t, err)
+
+	_, err = svc.GetSecret(context.Background(), "tool", genv.GetSecretInput{Key: "api-key"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create exec secret client for tool (defined in "+shared+":3:7, "+local+":3:11)")
+
+	cfg.SecretProvider = genv.SecretProvider{}
+	generator, err := genv.NewDotenvGenerator(context.Background(), genv.DotenvGeneratorConfig{Config: cfg})
+	require.NoError(t, err)
+
+	_, err = generator.FetchSecrets(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get secret API_KEY (defined in "+path+":4:5)")
+}
+
+func TestConfig_IsSecretEnv(t *testing.T) {
+	t.Parallel()
+
+	cfg := &genv.Config{
+		Envs: map[string]genv.EnvValue{
+			"PLAIN":    {Value: "value"},
+			"SECRET":   {SecretRef: &genv.SecretRef{Provider: "aws", Key: "key"}},
+			"TEMPLATE": {Template: "{{ .Env.SECRET }}"},
+		},
+	}
+
+	assert.False(t, cfg.IsSecretEnv("PLAIN"))
+	assert.True(t, cfg.IsSecretEnv("SECRET"))
+	assert.True(t, cfg.IsSecretEnv("TEMPLATE"))
+	assert.True(t, cfg.IsSecretEnv("FROM_ENV_FROM"))
+}
@@ -0,0 +1,389 @@
+package genv
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mrtc0/genv/provider/onepassword"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError represents a problem in the config, and where it is.
+type ValidationError struct {
+	// Source is the zero value if the location is unknown, e.g. when the
+	// config was not loaded with LoadConfig.
+	Source  Source
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Source == (Source{}) {
+		return e.Message
+	}
+
+	return e.Source.String() + ": " + e.Message
+}
+
+var unmarshalerType = reflect.TypeFor[yaml.Unmarshaler]()
+
+// checkFields reports the keys in the node that do not correspond to a field
+// of t, so that typos such as "secretRefs" are not silently ignored.
+func (l *configLoader) checkFields(node *yaml.Node, t reflect.Type, path string) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeFor[SecretProvider]():
+		return l.checkProviderFields(node, path)
+	case reflect.PointerTo(t).Implements(unmarshalerType):
+		return nil
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			i := slices.IndexFunc(fields, func(f yamlField) bool { return f.name == key.Value })
+			if i < 0 {
+				errs = append(errs, &ValidationError{
+					Source:  l.source(key),
+					Message: fmt.Sprintf("unknown field %q%s", key.Value, describePath(path)),
+				})
+				continue
+			}
+
+			errs = append(errs, l.checkFields(value, fields[i].field.Type, joinPath(path, key.Value))...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, l.checkFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+
+		for i, item := range node.Content {
+			errs = append(errs, l.checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+
+	return errs
+}
+
+// checkProviderFields reports the keys under secretProvider that are not
+// provider types, and the unknown fields of the providers whose type has a
+// ConfigType. The others are checked by the decoders of their types.
+func (l *configLoader) checkProviderFields(node *yaml.Node, path string) []error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var errs []error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		t, ok := lookupProviderType(key.Value)
+		if !ok {
+			errs = append(errs, &ValidationError{
+				Source:  l.source(key),
+				Message: fmt.Sprintf("unknown field %q%s", key.Value, describePath(path)),
+			})
+			continue
+		}
+		if t.ConfigType != nil {
+			errs = append(errs, l.checkFields(value, reflect.SliceOf(t.ConfigType), joinPath(path, key.Value))...)
+		}
+	}
+
+	return errs
+}
+
+// yamlField is a field of a struct as it appears in YAML.
+type yamlField struct {
+	name  string
+	field reflect.StructField
+}
+
+// yamlFields returns the fields of the struct by their YAML key in the order
+// they are declared, including the fields of inlined structs.
+func yamlFields(t reflect.Type) []yamlField {
+	var fields []yamlField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if slices.Contains(strings.Split(opts, ","), "inline") {
+			fields = append(fields, yamlFields(f.Type)...)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		fields = append(fields, yamlField{name: name, field: f})
+	}
+
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	return " in " + path
+}
+
+// Validate checks the config without accessing any secret provider, and
+// returns all the problems found joined into a single error. Each problem is
+// a *ValidationError.
+func (c *Config) Validate() error {
+	v := &validator{config: c, providers: make(map[string]string)}
+
+	v.validateProviders()
+	v.validateEnvs()
+	v.validateEnvFrom()
+
+	// Report the problems in the order they appear in the files. Problems
+	// whose location is unknown come last.
+	slices.SortStableFunc(v.errs, func(a, b error) int {
+		return compareSources(a.(*ValidationError).Source, b.(*ValidationError).Source)
+	})
+
+	return errors.Join(v.errs...)
+}
+
+// compareSources orders the sources by their position in the files. Unknown
+// sources come last.
+func compareSources(a, b Source) int {
+	switch {
+	case a == b:
+		return 0
+	case a == (Source{}):
+		return 1
+	case b == (Source{}):
+		return -1
+	}
+
+	return cmp.Or(strings.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+}
+
+type validator struct {
+	config *Config
+	// providers holds the type of each provider by ID.
+	providers map[string]string
+	errs      []error // *ValidationError
+}
+
+func (v *validator) errorf(source Source, format string, args ...any) {
+	v.errs = append(v.errs, &ValidationError{Source: source, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validateProviders() {
+	sp := v.config.SecretProvider
+
+	sources := make(map[string]Source)
+	addProvider := func(kind string, i int, id string) Source {
+		source := v.config.providerItemSource(kind, i)
+
+		switch {
+		case id == "":
+			v.errorf(source, "%s provider id is required", kind)
+		case v.providers[id] != "":
+			v.errorf(source, "duplicate provider id %q, already used by the %s provider%s", id, v.providers[id], describeSources(sources[id]))
+		default:
+			v.providers[id] = kind
+			sources[id] = source
+		}
+
+		return source
+	}
+
+	// Check the providers in the order they are defined, so that the later
+	// of the providers with the same ID is reported as the duplicate.
+	entries := sp.providers()
+	slices.SortStableFunc(entries, func(a, b providerEntry) int {
+		return compareSources(v.config.providerItemSource(a.kind, a.index), v.config.providerItemSource(b.kind, b.index))
+	})
+
+	for _, entry := range entries {
+		id := entry.config.ProviderID()
+		source := addProvider(entry.kind, entry.index, id)
+
+		errs := unjoin(entry.config.Settings().validate())
+		if validator, ok := entry.config.(ProviderConfigValidator); ok {
+			errs = append(errs, unjoin(validator.Validate())...)
+		}
+		for _, err := range errs {
+			v.errorf(source, "%s provider %q: %v", entry.kind, id, err)
+		}
+	}
+}
+
+func (s ProviderSettings) validate() error {
+	var errs []error
+	if s.Concurrency < 0 {
+		errs = append(errs, errors.New("concurrency must not be negative"))
+	}
+	if s.Timeout < 0 {
+		errs = append(errs, errors.New("timeout must not be negative"))
+	}
+	if s.Retries != nil && *s.Retries < 0 {
+		errs = append(errs, errors.New("retries must not be negative"))
+	}
+	if s.Backoff < 0 {
+		errs = append(errs, errors.New("backoff must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// unjoin returns the errors joined with errors.Join separately.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
+
+func (v *validator) validateEnvs() {
+	names := make([]string, 0, len(v.config.Envs))
+	for name := range v.config.Envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates := make(map[string]*templateEnv)
+	for _, name := range names {
+		env := v.config.Envs[name]
+		source := v.config.envSource(name)
+
+		set := 0
+		for _, ok := range []bool{env.Value != "", env.SecretRef != nil, env.Template != ""} {
+			if ok {
+				set++
+			}
+		}
+		if set > 1 {
+			v.errorf(source, "env %s: only one of value, secretRef and template can be set", name)
+		}
+
+		if env.SecretRef != nil {
+			v.validateSecretRef(source, "env "+name, *env.SecretRef)
+		}
+
+		if env.Template != "" {
+			tmpl, err := parseTemplateEnv(name, env.Template, parseOnlyTemplateFuncs)
+			if err != nil {
+				v.errorf(source, "env %s: invalid template: %v", name, err)
+				continue
+			}
+
+			for _, ref := range tmpl.secrets {
+				v.validateSecretRef(source, "env "+name, ref)
+			}
+			for _, dep := range tmpl.deps {
+				if !v.mayDefineEnv(dep) {
+					v.errorf(source, "env %s: template references undefined env %s", name, dep)
+				}
+			}
+
+			templates[name] = tmpl
+		}
+	}
+
+	if _, err := sortTemplateEnvs(templates); err != nil {
+		source := Source{}
+		var cycleErr *templateCycleError
+		if errors.As(err, &cycleErr) {
+			source = v.config.envSource(cycleErr.envs[0])
+		}
+		v.errorf(source, "%v", err)
+	}
+}
+
+func (v *validator) validateEnvFrom() {
+	for i, source := range v.config.EnvFrom {
+		loc := v.config.envFromSource(i)
+		what := fmt.Sprintf("envFrom[%d]", i)
+
+		if source.SecretRef == nil {
+			v.errorf(loc, "%s: secretRef is required", what)
+		} else {
+			v.validateSecretRef(loc, what, *source.SecretRef)
+		}
+
+		switch source.KeyCase {
+		case "", KeyCaseUpper, KeyCaseLower:
+		default:
+			v.errorf(loc, "%s: unsupported keyCase %q", what, source.KeyCase)
+		}
+	}
+}
+
+func (v *validator) validateSecretRef(source Source, what string, ref SecretRef) {
+	if ref.Provider == "" {
+		v.errorf(source, "%s: secret provider is required", what)
+	} else if _, ok := v.providers[ref.Provider]; !ok {
+		v.errorf(source, "%s: secret provider %q is not defined", what, ref.Provider)
+	}
+
+	if ref.Key == "" {
+		v.errorf(source, "%s: secret key is required", what)
+		return
+	}
+
+	if v.providers[ref.Provider] == "1password" {
+		if err := onepassword.ValidateSecretReference(ref.Key); err != nil {
+			v.errorf(source, "%s: %v", what, err)
+		}
+	}
+}
+
+// mayDefineEnv reports whether the env is defined in envs or could be
+// expanded from envFrom.
+func (v *validator) mayDefineEnv(name string) bool {
+	if _, ok := v.config.Envs[name]; ok {
+		return true
+	}
+
+	return slices.ContainsFunc(v.config.EnvFrom, func(source EnvFromSource) bool {
+		return source.mayDefine(name)
+	})
+}
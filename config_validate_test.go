@@ -0,0 +1,181 @@
+package genv_test
+
+import (
+	"testing"
+
+	"github.com/mrtc0/genv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_UnknownFields(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, t.TempDir(), ".genv.yaml", `secretProvider:
+  aws:
+    - id: aws
+      regoin: us-east-1
+envs:
+  DB_PASSWORD:
+    secretRefs:
+      provider: aws
+      key: db
+  API_KEY:
+    secretRef:
+      provder: aws
+      key: api-key
+profiles:
+  prod:
+    envz: {}
+`)
+
+	_, err := genv.LoadConfig(path)
+	require.Error(t, err)
+
+	assert.Equal(t, path+`:4:7: unknown field "regoin" in secretProvider.aws[0]
+`+path+`:7:5: unknown field "secretRefs" in envs.DB_PASSWORD
+`+path+`:12:7: unknown field "provder" in envs.API_KEY.secretRef
+`+path+`:16:5: unknown field "envz" in profiles.prod`, err.Error())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		config string
+		errMsg []string // empty means no error expected
+	}{
+		"valid": {
+			config: `secretProvider:
+  aws:
+    - id: aws
+      service: ParameterStore
+  1password:
+    - id: op
+  exec:
+    - id: tool
+      command: ["tool", "get"]
+envFrom:
+  - secretRef:
+      provider: aws
+      key: app
+    keyCase: upper
+envs:
+  DB_PASSWORD:
+    secretRef:
+      provider: op
+      key: op://vault/db/password
+  DB_URL:
+    template: 'postgres://{{ .Env.DB_USER }}:{{ .Env.DB_PASSWORD }}@{{ secret "tool" "host" }}'
+`,
+		},
+		"invalid providers": {
+			config: `secretProvider:
+  aws:
+    - id: aws
+      service: SecretManager
+  googleCloud:
+    - id: gcp
+      service: SecretManager
+  1password:
+    - id: aws
+  vault:
+    - id: vault
+      kvVersion: 3
+  exec:
+    - command: tool
+  plugin:
+    - id: broker
+      name: ../broker
+`,
+			errMsg: []string{
+				`:3:7: aws provider "aws": unsupported service "SecretManager"`,
+				`:6:7: googleCloud provider "gcp": projectID is required`,
+				`:9:7: duplicate provider id "aws", already used by the aws provider (defined in `,
+				`:11:7: vault provider "vault": unsupported kvVersion 3`,
+				`:14:7: exec provider id is required`,
+				`:16:7: plugin provider "broker": invalid plugin name "../broker": must consist of lowercase letters, digits, "-" and "_"`,
+			},
+		},
+		"invalid provider settings": {
+			config: `secretProvider:
+  aws:
+    - id: aws
+      service: SecretsManager
+      concurrency: -1
+      retries: -1
+      timeout: -1s
+`,
+			errMsg: []string{
+				`:3:7: aws provider "aws": concurrency must not be negative`,
+				`:3:7: aws provider "aws": timeout must not be negative`,
+				`:3:7: aws provider "aws": retries must not be negative`,
+			},
+		},
+		"invalid envs": {
+			config: `secretProvider:
+  1password:
+    - id: op
+envFrom:
+  - prefix: APP_
+envs:
+  BOTH:
+    value: a
+    secretRef:
+      provider: op
+      key: op://vault/item/field
+  UNDEFINED_PROVIDER:
+    secretRef:
+      provider: aws
+      key: db
+  MALFORMED_KEY:
+    secretRef:
+      provider: op
+      key: op://vault/item
+  TEMPLATE:
+    template: '{{ .Env.MISSING }}{{ secret "gcp" "key" }}'
+`,
+			errMsg: []string{
+				`:5:5: envFrom[0]: secretRef is required`,
+				`:8:5: env BOTH: only one of value, secretRef and template can be set`,
+				`:13:5: env UNDEFINED_PROVIDER: secret provider "aws" is not defined`,
+				`:17:5: env MALFORMED_KEY: secret reference "op://vault/item" must be op://<vault>/<item>/[<section>/]<field>`,
+				`:21:5: env TEMPLATE: secret provider "gcp" is not defined`,
+				`:21:5: env TEMPLATE: template references undefined env MISSING`,
+			},
+		},
+		"template cycle": {
+			config: `envs:
+  A:
+    template: '{{ .Env.B }}'
+  B:
+    template: '{{ .Env.A }}'
+`,
+			errMsg: []string{":3:5: template dependency cycle detected: A -> B -> A"},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := genv.LoadConfig(writeConfigFile(t, t.TempDir(), ".genv.yaml", tt.config))
+			require.NoError(t, err)
+
+			err = cfg.Validate()
+			if len(tt.errMsg) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			problems := err.(interface{ Unwrap() []error }).Unwrap()
+			require.Len(t, problems, len(tt.errMsg), err.Error())
+			for i, msg := range tt.errMsg {
+				var validationErr *genv.ValidationError
+				require.ErrorAs(t, problems[i], &validationErr)
+				assert.Contains(t, problems[i].Error(), msg)
+			}
+		})
+	}
+}
@@ -23,15 +23,31 @@ func Diff(ctx context.Context, cfg *Config, envMap map[string]string, ignoreValu
 // DiffEnvName compares the environment variables defined in the config with
 // the environment variables in the dotenv map, but only the names of the
 // environment variables are used to take the difference.
+//
+// The names of the envs expanded from EnvFrom are only known without
+// retrieving the secret when the fields are listed in Include. For the other
+// sources, envs in the dotenv map that may have been expanded from them are
+// not reported as removed.
+//
+// If the config was narrowed down with Config.Filter, only the selected envs
+// are compared.
 func DiffEnvName(ctx context.Context, cfg *Config, envMap map[string]string) (*diff.Diff, error) {
-	definedEnv := make(map[string]string)
-	for key := range cfg.Envs {
-		definedEnv[key] = notRetrievedValue
-	}
+	definedEnv, unknownSources := definedEnvNames(cfg)
+	envMap = cfg.filterEnvMap(envMap)
 
 	scrubbedEnvMap := make(map[string]string)
 	for key := range envMap {
 		scrubbedEnvMap[key] = notRetrievedValue
+
+		if _, ok := definedEnv[key]; ok {
+			continue
+		}
+		for _, source := range unknownSources {
+			if source.mayDefine(key) {
+				definedEnv[key] = notRetrievedValue
+				break
+			}
+		}
 	}
 
 	return diffEnvMap(scrubbedEnvMap, definedEnv), nil
@@ -40,6 +56,9 @@ func DiffEnvName(ctx context.Context, cfg *Config, envMap map[string]string) (*d
 // DiffEnv compares the environment variables defined in the config with
 // the environment variables in the dotenv map, including the values of the
 // environment variables.
+//
+// If the config was narrowed down with Config.Filter, only the selected envs
+// are compared.
 func DiffEnv(ctx context.Context, cfg *Config, envMap map[string]string) (*diff.Diff, error) {
 	generator, err := NewDotenvGenerator(ctx, DotenvGeneratorConfig{
 		Config: cfg,
@@ -47,13 +66,57 @@ func DiffEnv(ctx context.Context, cfg *Config, envMap map[string]string) (*diff.
 	if err != nil {
 		return nil, err
 	}
+	defer generator.Close()
 
 	fetched, err := generator.FetchSecrets(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return diffEnvMap(envMap, fetched), nil
+	return diffEnvMap(cfg.filterEnvMap(envMap), fetched), nil
+}
+
+// DiffConfigEnvName compares the names of the environment variables defined
+// in two configs, such as the configs of two profiles. No values are
+// retrieved, so the envs expanded from EnvFrom are only compared when the
+// fields are listed in Include.
+func DiffConfigEnvName(ctx context.Context, base, other *Config) (*diff.Diff, error) {
+	baseEnv, _ := definedEnvNames(base)
+	otherEnv, _ := definedEnvNames(other)
+
+	return diffEnvMap(baseEnv, otherEnv), nil
+}
+
+// definedEnvNames returns the names of the envs defined in the config, and
+// the EnvFrom sources whose env names cannot be known without retrieving the
+// secret. Only the envs and sources selected by the filter of the config
+// are returned.
+func definedEnvNames(cfg *Config) (map[string]string, []EnvFromSource) {
+	definedEnv := make(map[string]string)
+	for key, env := range cfg.Envs {
+		if cfg.selects(key, env.Tags) {
+			definedEnv[key] = notRetrievedValue
+		}
+	}
+
+	var unknownSources []EnvFromSource
+	for _, source := range cfg.EnvFrom {
+		names, ok := source.definedEnvNames()
+		if !ok {
+			if cfg.filter == nil || cfg.filter.matchTags(source.Tags) {
+				unknownSources = append(unknownSources, source)
+			}
+			continue
+		}
+
+		for _, name := range names {
+			if _, ok := cfg.Envs[name]; !ok && cfg.selects(name, source.Tags) {
+				definedEnv[name] = notRetrievedValue
+			}
+		}
+	}
+
+	return definedEnv, unknownSources
 }
 
 func diffEnvMap(old, new map[string]string) *diff.Diff {
@@ -39,6 +39,38 @@ func TestDiffEnvName(t *testing.T) {
 				Changed: map[string]diff.ChangeValue{},
 			},
 		},
+		"names of envs expanded from envFrom": {
+			cfg: &genv.Config{
+				Envs: map[string]genv.EnvValue{
+					"APP_ENV": {Value: "development"},
+				},
+				EnvFrom: []genv.EnvFromSource{
+					{
+						// The names are known from the include list
+						SecretRef: &genv.SecretRef{Provider: "example-account", Key: "db-credentials"},
+						Prefix:    "DB_",
+						KeyCase:   genv.KeyCaseUpper,
+						Include:   []string{"username", "password"},
+					},
+					{
+						// The names are unknown without retrieving the secret
+						SecretRef: &genv.SecretRef{Provider: "example-account", Key: "redis-credentials"},
+						Prefix:    "REDIS_",
+					},
+				},
+			},
+			dotenv: map[string]string{
+				"APP_ENV":     "development",
+				"DB_USERNAME": "user",
+				"REDIS_HOST":  "redis.example.com",
+				"REMOVED_ENV": "removed-value",
+			},
+			expected: diff.Diff{
+				Added:   map[string]string{"DB_PASSWORD": "(value not retrieved)"},
+				Removed: map[string]string{"REMOVED_ENV": "(value not retrieved)"},
+				Changed: map[string]diff.ChangeValue{},
+			},
+		},
 	}
 
 	for name, tt := range testCases {
@@ -53,3 +85,32 @@ func TestDiffEnvName(t *testing.T) {
 		})
 	}
 }
+
+func TestDiffConfigEnvName(t *testing.T) {
+	t.Parallel()
+
+	base := &genv.Config{
+		Envs: map[string]genv.EnvValue{
+			"APP_ENV":     {Value: "development"},
+			"DEBUG":       {Value: "true"},
+			"DB_PASSWORD": {SecretRef: &genv.SecretRef{Provider: "dev", Key: "db"}},
+		},
+	}
+	other := &genv.Config{
+		Envs: map[string]genv.EnvValue{
+			"APP_ENV":     {Value: "production"},
+			"DB_PASSWORD": {SecretRef: &genv.SecretRef{Provider: "prod", Key: "db"}},
+		},
+		EnvFrom: []genv.EnvFromSource{
+			{SecretRef: &genv.SecretRef{Provider: "prod", Key: "sentry"}, Include: []string{"SENTRY_DSN"}},
+		},
+	}
+
+	actual, err := genv.DiffConfigEnvName(context.Background(), base, other)
+	require.NoError(t, err)
+	assert.Equal(t, diff.Diff{
+		Added:   map[string]string{"SENTRY_DSN": "(value not retrieved)"},
+		Removed: map[string]string{"DEBUG": "(value not retrieved)"},
+		Changed: map[string]diff.ChangeValue{},
+	}, *actual)
+}
@@ -0,0 +1,197 @@
+package dotenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Document is a dotenv file that can be edited while keeping its comments,
+// blank lines, order, quoting and the keys that are not edited byte-identical.
+type Document struct {
+	// parts are the pieces of the file in order. The text between the
+	// assignments, such as comments and blank lines, is kept in parts
+	// without an assignment.
+	parts []part
+}
+
+type part struct {
+	// text is the source text of the part. For an assignment, it is the
+	// text before the value, e.g. "export KEY=".
+	text string
+
+	assignment bool
+	key        string
+	value      string
+	// rawValue is the value as written in the source, including its quotes.
+	rawValue string
+	// suffix is the text after the value, e.g. " # comment".
+	suffix string
+}
+
+// ParseDocument parses the content of a dotenv file into a Document.
+func ParseDocument(content []byte) (*Document, error) {
+	src := string(content)
+
+	entries, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Document{}
+	pos := 0
+	for _, e := range entries {
+		if pos < e.start {
+			d.parts = append(d.parts, part{text: src[pos:e.start]})
+		}
+
+		d.parts = append(d.parts, part{
+			text:       src[e.start:e.valueStart],
+			assignment: true,
+			key:        e.key,
+			value:      e.value,
+			rawValue:   src[e.valueStart:e.valueEnd],
+			suffix:     src[e.valueEnd:e.end],
+		})
+		pos = e.end
+	}
+	if pos < len(src) {
+		d.parts = append(d.parts, part{text: src[pos:]})
+	}
+
+	return d, nil
+}
+
+// ReadDocument reads the dotenv file into a Document. If the file does not
+// exist, an empty Document is returned.
+func ReadDocument(filename string) (*Document, error) {
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Document{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := ParseDocument(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return d, nil
+}
+
+// Keys returns the keys assigned in the document in order, without duplicates.
+func (d *Document) Keys() []string {
+	var keys []string
+	seen := make(map[string]struct{})
+	for _, p := range d.parts {
+		if _, ok := seen[p.key]; !p.assignment || ok {
+			continue
+		}
+		seen[p.key] = struct{}{}
+		keys = append(keys, p.key)
+	}
+
+	return keys
+}
+
+// Get returns the value of the key. If the key is assigned more than once,
+// the last value is returned, as in Unmarshal.
+func (d *Document) Get(key string) (string, bool) {
+	if i := d.lastAssignment(key); i >= 0 {
+		return d.parts[i].value, true
+	}
+
+	return "", false
+}
+
+// Set sets the value of the key. An existing assignment is updated in place,
+// keeping the text around the value; the assignment is left untouched if the
+// value does not change. A new key is appended to the end of the document.
+func (d *Document) Set(key, value string) {
+	i := d.lastAssignment(key)
+	if i < 0 {
+		d.append(key, value)
+		return
+	}
+
+	p := &d.parts[i]
+	if p.value == value {
+		return
+	}
+
+	p.value = value
+	p.rawValue = marshalValue(value)
+	if strings.HasPrefix(p.suffix, "#") {
+		// Keep the inline comment from becoming part of an unquoted value.
+		p.suffix = " " + p.suffix
+	}
+}
+
+// Map returns the keys and values in the document, as Unmarshal does.
+func (d *Document) Map() map[string]string {
+	envMap := make(map[string]string)
+	for _, p := range d.parts {
+		if p.assignment {
+			envMap[p.key] = p.value
+		}
+	}
+
+	return envMap
+}
+
+// Bytes returns the content of the document.
+func (d *Document) Bytes() []byte {
+	var b bytes.Buffer
+	for _, p := range d.parts {
+		b.WriteString(p.text)
+		if p.assignment {
+			b.WriteString(p.rawValue)
+			b.WriteString(p.suffix)
+		}
+	}
+
+	return b.Bytes()
+}
+
+func (d *Document) append(key, value string) {
+	if n := len(d.parts); n > 0 {
+		last := d.parts[n-1]
+		if last.assignment || !strings.HasSuffix(last.text, "\n") {
+			d.parts = append(d.parts, part{text: d.newline()})
+		}
+	}
+
+	d.parts = append(d.parts,
+		part{text: key + "=", assignment: true, key: key, value: value, rawValue: marshalValue(value)},
+		part{text: d.newline()},
+	)
+}
+
+// newline returns the line ending used in the document.
+func (d *Document) newline() string {
+	for _, p := range d.parts {
+		if strings.Contains(p.text, "\r\n") {
+			return "\r\n"
+		}
+		if strings.Contains(p.text, "\n") {
+			return "\n"
+		}
+	}
+
+	return "\n"
+}
+
+func (d *Document) lastAssignment(key string) int {
+	for i := len(d.parts) - 1; i >= 0; i-- {
+		if d.parts[i].assignment && d.parts[i].key == key {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,170 @@
+package dotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrtc0/genv/dotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "compat", "*.env"))
+	require.NoError(t, err)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			t.Parallel()
+
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			doc, err := dotenv.ParseDocument(content)
+			require.NoError(t, err)
+			assert.Equal(t, string(content), string(doc.Bytes()))
+
+			expected, err := dotenv.Unmarshal(content)
+			require.NoError(t, err)
+			assert.Equal(t, expected, doc.Map())
+		})
+	}
+}
+
+func TestDocument_Set(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		content  string
+		set      map[string]string
+		expected string
+	}{
+		"update in place": {
+			content: `# Managed by genv
+export API_KEY="old" # rotated monthly
+
+# Hand-maintained
+DEBUG=true
+`,
+			set: map[string]string{"API_KEY": "new"},
+			expected: `# Managed by genv
+export API_KEY="new" # rotated monthly
+
+# Hand-maintained
+DEBUG=true
+`,
+		},
+		"unchanged value keeps its quoting": {
+			content:  "A='same'\nB = 1\n",
+			set:      map[string]string{"A": "same", "B": "1"},
+			expected: "A='same'\nB = 1\n",
+		},
+		"unquoted value": {
+			content:  "A=old   # comment\n",
+			set:      map[string]string{"A": "has space"},
+			expected: "A=\"has space\"   # comment\n",
+		},
+		"empty value before comment": {
+			content:  "A= # comment\n",
+			set:      map[string]string{"A": "value"},
+			expected: "A= \"value\" # comment\n",
+		},
+		"multi-line value": {
+			content:  "KEY=\"line 1\nline 2\"\nOTHER=other\n",
+			set:      map[string]string{"KEY": "line 3"},
+			expected: "KEY=\"line 3\"\nOTHER=other\n",
+		},
+		"last of duplicate keys": {
+			content:  "A=first\nA=second\n",
+			set:      map[string]string{"A": "third"},
+			expected: "A=first\nA=\"third\"\n",
+		},
+		"new key": {
+			content:  "# comment\nA=a\n",
+			set:      map[string]string{"B": "b"},
+			expected: "# comment\nA=a\nB=\"b\"\n",
+		},
+		"new key without trailing newline": {
+			content:  "A=a",
+			set:      map[string]string{"B": "b"},
+			expected: "A=a\nB=\"b\"\n",
+		},
+		"new key with CRLF": {
+			content:  "A=a\r\n",
+			set:      map[string]string{"B": "b"},
+			expected: "A=a\r\nB=\"b\"\r\n",
+		},
+		"new key in empty document": {
+			content:  "",
+			set:      map[string]string{"A": "a"},
+			expected: "A=\"a\"\n",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := dotenv.ParseDocument([]byte(tt.content))
+			require.NoError(t, err)
+
+			for k, v := range tt.set {
+				doc.Set(k, v)
+			}
+			assert.Equal(t, tt.expected, string(doc.Bytes()))
+
+			// The result is still a valid dotenv file with the values set
+			actual, err := dotenv.Unmarshal(doc.Bytes())
+			require.NoError(t, err)
+			for k, v := range tt.set {
+				assert.Equal(t, v, actual[k])
+			}
+		})
+	}
+}
+
+func TestDocument_Keys(t *testing.T) {
+	t.Parallel()
+
+	doc, err := dotenv.ParseDocument([]byte("B=b\n# A=a\nA=a\nB=c\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"B", "A"}, doc.Keys())
+
+	v, ok := doc.Get("B")
+	assert.True(t, ok)
+	assert.Equal(t, "c", v)
+
+	_, ok = doc.Get("C")
+	assert.False(t, ok)
+}
+
+func TestReadDocument_NotExist(t *testing.T) {
+	t.Parallel()
+
+	doc, err := dotenv.ReadDocument(filepath.Join(t.TempDir(), ".env"))
+	require.NoError(t, err)
+	assert.Empty(t, doc.Keys())
+}
+
+func TestWriteDocument(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	original := "# keep me\nA=a\n\nLOCAL_ONLY=1\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0600))
+
+	doc, err := dotenv.ReadDocument(path)
+	require.NoError(t, err)
+	doc.Set("A", "updated")
+
+	require.NoError(t, dotenv.WriteDocument(path, doc))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(original, "A=a", `A="updated"`, 1), string(content))
+}
@@ -8,23 +8,65 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/mrtc0/genv/internal/fileutil"
 )
 
-func WriteFile(filename string, envMap map[string]string) error {
-	content, err := Marshal(envMap)
-	if err != nil {
-		return err
+// DefaultFileMode is the mode of the files created by WriteFile.
+const DefaultFileMode = fileutil.DefaultFileMode
+
+type writeOptions struct {
+	mode           os.FileMode
+	followSymlinks bool
+}
+
+// WriteOption configures how WriteFile writes the file.
+type WriteOption func(*writeOptions)
+
+// WithFileMode sets the mode of the file. By default, the mode of the
+// existing file is kept, and new files are created with DefaultFileMode.
+func WithFileMode(mode os.FileMode) WriteOption {
+	return func(o *writeOptions) {
+		o.mode = mode
 	}
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+}
+
+// WithFollowSymlinks makes WriteFile write to the target of the file if it
+// is a symbolic link. By default, WriteFile refuses to write to symbolic links.
+func WithFollowSymlinks() WriteOption {
+	return func(o *writeOptions) {
+		o.followSymlinks = true
 	}
-	defer file.Close()
-	_, err = file.WriteString(content + "\n")
+}
+
+// WriteFile writes the envs to the file atomically: the content is written
+// to a temporary file in the same directory, which then replaces the file.
+// Either the old or the new content is left even if writing fails. The mode
+// and ownership of the existing file are kept.
+func WriteFile(filename string, envMap map[string]string, opts ...WriteOption) error {
+	content, err := Marshal(envMap)
 	if err != nil {
 		return err
 	}
-	return file.Sync()
+
+	return writeFile(filename, []byte(content+"\n"), opts...)
+}
+
+// WriteDocument writes the document to the file in the same way as WriteFile.
+func WriteDocument(filename string, doc *Document, opts ...WriteOption) error {
+	return writeFile(filename, doc.Bytes(), opts...)
+}
+
+func writeFile(filename string, content []byte, opts ...WriteOption) error {
+	o := &writeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return fileutil.WriteFile(filename, content, fileutil.WriteOptions{
+		Mode:           o.mode,
+		FollowSymlinks: o.followSymlinks,
+	})
 }
 
 func ReadFile(filename string) (map[string]string, error) {
@@ -41,7 +83,7 @@ func ReadFile(filename string) (map[string]string, error) {
 
 	envMap, err := Unmarshal(buf.Bytes())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", filename, err)
 	}
 
 	return envMap, nil
@@ -52,52 +94,37 @@ func Marshal(envMap map[string]string) (string, error) {
 	lines := make([]string, 0, len(envMap))
 
 	for k, v := range envMap {
-		if d, err := strconv.Atoi(v); err == nil {
-			lines = append(lines, fmt.Sprintf(`%s=%d`, k, d))
-		} else {
-			lines = append(lines, fmt.Sprintf(`%s="%s"`, k, backslashEscape(v)))
-		}
+		lines = append(lines, k+"="+marshalValue(v))
 	}
 
 	sort.Strings(lines)
 	return strings.Join(lines, "\n"), nil
 }
 
-func Unmarshal(contnet []byte) (map[string]string, error) {
-	lines := strings.Split(string(contnet), "\n")
-	envMap := make(map[string]string)
-
-	for _, line := range lines {
-		if skipLine(line) {
-			continue
-		}
-
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid line: %s", line)
-		}
-
-		envMap[parts[0]] = extractValue(parts[1])
+// marshalValue returns the value as written in a dotenv file. Integers are
+// written as they are, and any other value is double-quoted.
+func marshalValue(v string) string {
+	if _, err := strconv.Atoi(v); err == nil {
+		return v
 	}
-	return envMap, nil
-}
 
-func extractValue(part string) string {
-	if len(part) == 0 {
-		return ""
-	}
+	return `"` + backslashEscape(v) + `"`
+}
 
-	if part[0] == '"' && part[len(part)-1] == '"' {
-		part = part[1 : len(part)-1]
-		return backslashUnescape(part)
+// Unmarshal parses the content of a dotenv file. If a key is assigned more
+// than once, the last value is used. See parser for the supported syntax.
+func Unmarshal(content []byte) (map[string]string, error) {
+	entries, err := parse(string(content))
+	if err != nil {
+		return nil, err
 	}
 
-	if part[0] == '\'' && part[len(part)-1] == '\'' {
-		part = part[1 : len(part)-1]
-		return backslashUnescape(part)
+	envMap := make(map[string]string, len(entries))
+	for _, e := range entries {
+		envMap[e.key] = e.value
 	}
 
-	return backslashUnescape(part)
+	return envMap, nil
 }
 
 func backslashEscape(line string) string {
@@ -105,28 +132,8 @@ func backslashEscape(line string) string {
 	line = strings.Replace(line, "\n", `\n`, -1)
 	line = strings.Replace(line, "\r", `\r`, -1)
 	line = strings.Replace(line, `"`, `\"`, -1)
+	// Shells and Docker Compose expand variables in double quotes.
+	line = strings.Replace(line, `$`, `\$`, -1)
 
 	return line
 }
-
-func backslashUnescape(part string) string {
-	part = strings.Replace(part, `\\`, `\`, -1)
-	part = strings.Replace(part, `\n`, "\n", -1)
-	part = strings.Replace(part, `\r`, "\r", -1)
-	part = strings.Replace(part, `\"`, `"`, -1)
-
-	return part
-}
-
-func skipLine(line string) bool {
-	line = strings.TrimSpace(line)
-	if len(line) == 0 {
-		return true
-	}
-
-	if line[0] == '#' || line[0] == '\n' {
-		return true
-	}
-
-	return false
-}
@@ -1,6 +1,10 @@
 package dotenv_test
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mrtc0/genv/dotenv"
@@ -69,6 +73,12 @@ EXAMPLE_SECRET="secret-value"`,
 			},
 			expected: `EXAMPLE_ENV="example\\value"`,
 		},
+		"value has dollar sign": {
+			envMap: map[string]string{
+				"EXAMPLE_ENV": `pa$$word${HOME}`,
+			},
+			expected: `EXAMPLE_ENV="pa\$\$word\${HOME}"`,
+		},
 	}
 
 	for name, tt := range testCases {
@@ -185,3 +195,223 @@ EXAMPLE_ENV="example-value"`),
 		})
 	}
 }
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	envMap := map[string]string{"KEY": "new"}
+
+	testCases := map[string]struct {
+		setup        func(t *testing.T, dir string) string
+		opts         []dotenv.WriteOption
+		expectedMode os.FileMode
+		errMsg       string
+	}{
+		"new file": {
+			setup:        func(t *testing.T, dir string) string { return filepath.Join(dir, ".env") },
+			expectedMode: 0600,
+		},
+		"new file with mode": {
+			setup:        func(t *testing.T, dir string) string { return filepath.Join(dir, ".env") },
+			opts:         []dotenv.WriteOption{dotenv.WithFileMode(0640)},
+			expectedMode: 0640,
+		},
+		"existing file keeps its mode": {
+			setup: func(t *testing.T, dir string) string {
+				path := filepath.Join(dir, ".env")
+				require.NoError(t, os.WriteFile(path, []byte("KEY=old\n"), 0644))
+				require.NoError(t, os.Chmod(path, 0644))
+				return path
+			},
+			expectedMode: 0644,
+		},
+		"existing file with mode": {
+			setup: func(t *testing.T, dir string) string {
+				path := filepath.Join(dir, ".env")
+				require.NoError(t, os.WriteFile(path, []byte("KEY=old\n"), 0644))
+				return path
+			},
+			opts:         []dotenv.WriteOption{dotenv.WithFileMode(0600)},
+			expectedMode: 0600,
+		},
+		"symbolic link": {
+			setup: func(t *testing.T, dir string) string {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "target"), []byte("KEY=old\n"), 0600))
+				path := filepath.Join(dir, ".env")
+				require.NoError(t, os.Symlink("target", path))
+				return path
+			},
+			errMsg: "it is a symbolic link",
+		},
+		"directory": {
+			setup: func(t *testing.T, dir string) string {
+				path := filepath.Join(dir, ".env")
+				require.NoError(t, os.Mkdir(path, 0700))
+				return path
+			},
+			errMsg: "it is not a regular file",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := tt.setup(t, dir)
+
+			err := dotenv.WriteFile(path, envMap, tt.opts...)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				require.NoError(t, err)
+
+				content, err := os.ReadFile(path)
+				require.NoError(t, err)
+				assert.Equal(t, "KEY=\"new\"\n", string(content))
+
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedMode, info.Mode().Perm())
+			}
+
+			// No temporary file is left behind
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			for _, entry := range entries {
+				assert.NotContains(t, entry.Name(), ".tmp-")
+			}
+		})
+	}
+}
+
+func TestWriteFile_FollowSymlinks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	require.NoError(t, os.WriteFile(target, []byte("KEY=old\n"), 0640))
+	require.NoError(t, os.Chmod(target, 0640))
+	path := filepath.Join(dir, ".env")
+	require.NoError(t, os.Symlink("target", path))
+
+	require.NoError(t, dotenv.WriteFile(path, map[string]string{"KEY": "new"}, dotenv.WithFollowSymlinks()))
+
+	// The link is kept and the target is replaced
+	info, err := os.Lstat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink)
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "KEY=\"new\"\n", string(content))
+
+	info, err = os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+// TestUnmarshal_Compatibility parses the files in testdata/compat, which are
+// written in the syntax accepted by the common dotenv implementations, and
+// compares the result with the JSON file of the same name.
+func TestUnmarshal_Compatibility(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "compat", "*.env"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			t.Parallel()
+
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			expectedJSON, err := os.ReadFile(strings.TrimSuffix(file, ".env") + ".json")
+			require.NoError(t, err)
+
+			var expected map[string]string
+			require.NoError(t, json.Unmarshal(expectedJSON, &expected))
+
+			actual, err := dotenv.Unmarshal(content)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestUnmarshal_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		content string
+		line    int
+		errMsg  string
+	}{
+		"missing =": {
+			content: "A=a\nB\n",
+			line:    2,
+			errMsg:  `line 2: missing "=" after key B`,
+		},
+		"invalid key": {
+			content: "A=a\n\nB C=c\n",
+			line:    3,
+			errMsg:  `line 3: unexpected character 'C' in key B`,
+		},
+		"no key": {
+			content: "=value\n",
+			line:    1,
+			errMsg:  `line 1: unexpected character '=', expected a key`,
+		},
+		"unterminated double quote": {
+			content: "A=a\nB=\"b\nC=c\n",
+			line:    2,
+			errMsg:  "line 2: unterminated double-quoted value",
+		},
+		"unterminated single quote": {
+			content: "A='a\n",
+			line:    1,
+			errMsg:  "line 1: unterminated single-quoted value",
+		},
+		"text after closing quote": {
+			content: "A=\"multi\nline\" trailing\n",
+			line:    2,
+			errMsg:  `line 2: unexpected character 't' after the quoted value of A`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := dotenv.Unmarshal([]byte(tt.content))
+			require.EqualError(t, err, tt.errMsg)
+
+			var parseErr *dotenv.ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.line, parseErr.Line)
+		})
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	envMap := map[string]string{
+		"PLAIN":     "value",
+		"NUMBER":    "42",
+		"SPECIAL":   "a \"quoted\" \\ # $HOME 'single'",
+		"DOLLAR":    "pa$$word ${HOME} \\$",
+		"MULTILINE": "line 1\nline 2\r\n",
+		"EMPTY":     "",
+	}
+
+	content, err := dotenv.Marshal(envMap)
+	require.NoError(t, err)
+
+	actual, err := dotenv.Unmarshal([]byte(content))
+	require.NoError(t, err)
+	assert.Equal(t, envMap, actual)
+}
@@ -0,0 +1,273 @@
+package dotenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is returned when a dotenv file cannot be parsed.
+type ParseError struct {
+	// Line is the 1-based line number the error occurred on.
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// entry is a single KEY=value assignment in a dotenv file.
+type entry struct {
+	key   string
+	value string
+	// line is the line number the assignment starts on.
+	line int
+	// start and end are the offsets of the assignment in the source,
+	// including a trailing comment but not the newline. valueStart and
+	// valueEnd are the offsets of the value, including its quotes.
+	start, valueStart, valueEnd, end int
+}
+
+// parser parses dotenv files, following the behaviour common to the
+// popular dotenv implementations:
+//
+//   - Lines starting with # are comments, and blank lines are ignored.
+//   - Assignments may be prefixed with "export", and may have spaces around =.
+//   - Keys consist of letters, digits, "_", "." and "-".
+//   - Unquoted values end at the end of the line or at a # preceded by
+//     whitespace, and surrounding whitespace is trimmed.
+//   - Single-quoted and backquoted values are taken literally.
+//   - Double-quoted values support the escape sequences \n, \r, \t, \", \\
+//     and \$. Other backslashes are kept as they are.
+//   - Quoted values may span multiple lines.
+//   - CRLF line endings are treated as LF.
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func parse(src string) ([]entry, error) {
+	p := &parser{src: src, line: 1}
+
+	var entries []entry
+	for {
+		p.skipSpacesAndNewlines()
+		if p.eof() {
+			return entries, nil
+		}
+
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		e, err := p.parseEntry()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
+
+func (p *parser) parseEntry() (entry, error) {
+	e := entry{line: p.line, start: p.pos}
+
+	if strings.HasPrefix(p.src[p.pos:], "export") && p.pos+6 < len(p.src) && isBlank(p.src[p.pos+6]) {
+		p.pos += len("export")
+		p.skipBlanks()
+	}
+
+	start := p.pos
+	for !p.eof() && isKeyChar(p.peek()) {
+		p.pos++
+	}
+	e.key = p.src[start:p.pos]
+	if e.key == "" {
+		return e, p.errorf("unexpected character %q, expected a key", p.peek())
+	}
+
+	p.skipBlanks()
+	switch {
+	case p.eof() || p.atNewline():
+		return e, p.errorf("missing \"=\" after key %s", e.key)
+	case p.peek() != '=':
+		return e, p.errorf("unexpected character %q in key %s", p.peek(), e.key)
+	}
+	p.pos++
+	p.skipBlanks()
+	e.valueStart = p.pos
+
+	if p.eof() {
+		e.valueEnd, e.end = p.pos, p.pos
+		return e, nil
+	}
+
+	var err error
+	switch quote := p.peek(); quote {
+	case '"':
+		e.value, err = p.parseDoubleQuoted()
+	case '\'', '`':
+		e.value, err = p.parseLiteral(quote)
+	default:
+		e.value = p.parseUnquoted()
+		e.valueEnd = e.valueStart + len(e.value)
+		p.skipLine()
+		e.end = p.pos
+		return e, nil
+	}
+	if err != nil {
+		return e, err
+	}
+	e.valueEnd = p.pos
+
+	// Only a comment may follow the closing quote.
+	p.skipBlanks()
+	switch {
+	case p.eof() || p.atNewline():
+	case p.peek() == '#':
+		p.skipLine()
+	default:
+		return e, p.errorf("unexpected character %q after the quoted value of %s", p.peek(), e.key)
+	}
+	e.end = p.pos
+
+	return e, nil
+}
+
+// parseUnquoted returns the unquoted value, stopping at an inline comment or
+// the end of the line.
+func (p *parser) parseUnquoted() string {
+	start := p.pos
+	for !p.eof() && !p.atNewline() {
+		if p.peek() == '#' && (p.pos == start || isBlank(p.src[p.pos-1])) {
+			break
+		}
+		p.pos++
+	}
+
+	return strings.TrimRight(p.src[start:p.pos], " \t")
+}
+
+func (p *parser) parseDoubleQuoted() (string, error) {
+	startLine := p.line
+	p.pos++ // opening quote
+
+	var b strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if p.pos+1 < len(p.src) {
+				if r, ok := unescape(p.src[p.pos+1]); ok {
+					b.WriteByte(r)
+					p.pos += 2
+					continue
+				}
+			}
+		case '\r':
+			if p.atNewline() {
+				// CRLF is read as LF
+				p.pos++
+				continue
+			}
+		case '\n':
+			p.line++
+		}
+
+		b.WriteByte(c)
+		p.pos++
+	}
+
+	return "", &ParseError{Line: startLine, Msg: "unterminated double-quoted value"}
+}
+
+func (p *parser) parseLiteral(quote byte) (string, error) {
+	startLine := p.line
+	p.pos++ // opening quote
+
+	start := p.pos
+	for !p.eof() {
+		switch p.peek() {
+		case quote:
+			value := strings.ReplaceAll(p.src[start:p.pos], "\r\n", "\n")
+			p.pos++
+			return value, nil
+		case '\n':
+			p.line++
+		}
+		p.pos++
+	}
+
+	kind := "single-quoted"
+	if quote == '`' {
+		kind = "backquoted"
+	}
+
+	return "", &ParseError{Line: startLine, Msg: "unterminated " + kind + " value"}
+}
+
+func unescape(c byte) (byte, bool) {
+	switch c {
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	case '"', '\\', '$':
+		return c, true
+	default:
+		return 0, false
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &ParseError{Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *parser) skipBlanks() {
+	for !p.eof() && isBlank(p.peek()) {
+		p.pos++
+	}
+}
+
+// atNewline reports whether the parser is at a LF or CRLF.
+func (p *parser) atNewline() bool {
+	return p.peek() == '\n' || strings.HasPrefix(p.src[p.pos:], "\r\n")
+}
+
+func (p *parser) skipSpacesAndNewlines() {
+	for !p.eof() && (isBlank(p.peek()) || p.atNewline()) {
+		if p.peek() == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+}
+
+// skipLine moves to the newline at the end of the current line.
+func (p *parser) skipLine() {
+	for !p.eof() && !p.atNewline() {
+		p.pos++
+	}
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-'
+}
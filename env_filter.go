@@ -0,0 +1,176 @@
+package genv
+
+import (
+	"maps"
+	"slices"
+)
+
+// EnvFilter selects envs by name and tag. The zero value selects all envs.
+type EnvFilter struct {
+	// Only selects the envs with the names. If empty, envs are not
+	// selected by name.
+	Only []string
+	// Exclude removes the envs with the names from the selection.
+	Exclude []string
+	// Tags selects the envs with any of the tags. If empty, envs are not
+	// selected by tag.
+	Tags []string
+}
+
+// IsZero reports whether the filter selects all envs.
+func (f EnvFilter) IsZero() bool {
+	return len(f.Only) == 0 && len(f.Exclude) == 0 && len(f.Tags) == 0
+}
+
+// Match reports whether the env with the name and tags is selected.
+func (f EnvFilter) Match(name string, tags []string) bool {
+	return f.matchName(name) && f.matchTags(tags)
+}
+
+func (f EnvFilter) matchName(name string) bool {
+	if len(f.Only) > 0 && !slices.Contains(f.Only, name) {
+		return false
+	}
+
+	return !slices.Contains(f.Exclude, name)
+}
+
+func (f EnvFilter) matchTags(tags []string) bool {
+	if len(f.Tags) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(tags, func(tag string) bool {
+		return slices.Contains(f.Tags, tag)
+	})
+}
+
+// Filter returns a copy of the config narrowed down to the envs selected by
+// the filter, so that the secrets of the other envs are never retrieved.
+//
+// The envs that the templates of the selected envs refer to are kept too, as
+// they are needed to render the templates, but they are not included in the
+// results of DotenvGenerator.FetchSecrets and Diff.
+func (c *Config) Filter(f EnvFilter) *Config {
+	if f.IsZero() {
+		return c
+	}
+
+	filtered := *c
+	filtered.filter = &f
+	filtered.Envs = make(map[string]EnvValue)
+	filtered.EnvFrom = nil
+	filtered.envFromIndex = []int{}
+
+	// deps are the envs referred to by templates that are not in Envs, and
+	// may be expanded from EnvFrom.
+	var deps []string
+	var keep func(name string)
+	keep = func(name string) {
+		if _, ok := filtered.Envs[name]; ok {
+			return
+		}
+
+		env, ok := c.Envs[name]
+		if !ok {
+			deps = append(deps, name)
+			return
+		}
+		filtered.Envs[name] = env
+
+		if env.Template == "" {
+			return
+		}
+		// Templates that cannot be parsed are reported when they are rendered.
+		if t, err := parseTemplateEnv(name, env.Template, parseOnlyTemplateFuncs); err == nil {
+			for _, dep := range t.deps {
+				keep(dep)
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Envs)) {
+		if f.Match(name, c.Envs[name].Tags) {
+			keep(name)
+		}
+	}
+
+	for i, source := range c.EnvFrom {
+		// Only retrieve the secret if it may define a selected env, or an env
+		// a selected template depends on.
+		selected := f.matchTags(source.Tags) && c.mayDefineSelectedName(source, f)
+		if selected || slices.ContainsFunc(deps, source.mayDefine) {
+			filtered.EnvFrom = append(filtered.EnvFrom, source)
+			filtered.envFromIndex = append(filtered.envFromIndex, c.originalEnvFromIndex(i))
+		}
+	}
+
+	return &filtered
+}
+
+// mayDefineSelectedName reports whether the source may define an env whose
+// name is selected by the filter and that is not overridden by Envs.
+func (c *Config) mayDefineSelectedName(source EnvFromSource, f EnvFilter) bool {
+	selected := func(name string) bool {
+		_, defined := c.Envs[name]
+		return !defined && f.matchName(name)
+	}
+
+	if len(f.Only) > 0 {
+		return slices.ContainsFunc(f.Only, func(name string) bool {
+			return selected(name) && source.mayDefine(name)
+		})
+	}
+	if names, ok := source.definedEnvNames(); ok {
+		return slices.ContainsFunc(names, selected)
+	}
+
+	return true
+}
+
+func (c *Config) originalEnvFromIndex(i int) int {
+	if c.envFromIndex != nil {
+		return c.envFromIndex[i]
+	}
+
+	return i
+}
+
+// selects reports whether the env with the name and tags is selected by the
+// filter of the config.
+func (c *Config) selects(name string, tags []string) bool {
+	return c.filter == nil || c.filter.Match(name, tags)
+}
+
+// filterEnvMap returns the entries of the dotenv map that the filter of the
+// config selects. With tags, only the envs the config may define with those
+// tags are selected, as the tags of the other envs are unknown.
+func (c *Config) filterEnvMap(envMap map[string]string) map[string]string {
+	if c.filter == nil {
+		return envMap
+	}
+
+	filtered := make(map[string]string)
+	for name, value := range envMap {
+		if !c.filter.matchName(name) {
+			continue
+		}
+		if len(c.filter.Tags) > 0 && !c.mayDefineWithSelectedTags(name) {
+			continue
+		}
+
+		filtered[name] = value
+	}
+
+	return filtered
+}
+
+func (c *Config) mayDefineWithSelectedTags(name string) bool {
+	if env, ok := c.Envs[name]; ok {
+		return c.filter.matchTags(env.Tags)
+	}
+
+	return slices.ContainsFunc(c.EnvFrom, func(source EnvFromSource) bool {
+		return source.mayDefine(name) && c.filter.matchTags(source.Tags)
+	})
+}
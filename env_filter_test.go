@@ -0,0 +1,211 @@
+package genv_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/mrtc0/genv"
+	"github.com/mrtc0/genv/diff"
+	"github.com/mrtc0/genv/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvFilter_Match(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		filter   genv.EnvFilter
+		name     string
+		tags     []string
+		expected bool
+	}{
+		"zero filter":         {filter: genv.EnvFilter{}, name: "API_KEY", expected: true},
+		"only":                {filter: genv.EnvFilter{Only: []string{"API_KEY"}}, name: "API_KEY", expected: true},
+		"not in only":         {filter: genv.EnvFilter{Only: []string{"API_KEY"}}, name: "DB_PASSWORD", expected: false},
+		"excluded":            {filter: genv.EnvFilter{Exclude: []string{"API_KEY"}}, name: "API_KEY", expected: false},
+		"only and excluded":   {filter: genv.EnvFilter{Only: []string{"API_KEY"}, Exclude: []string{"API_KEY"}}, name: "API_KEY", expected: false},
+		"any tag":             {filter: genv.EnvFilter{Tags: []string{"frontend", "backend"}}, name: "API_KEY", tags: []string{"backend"}, expected: true},
+		"no tag":              {filter: genv.EnvFilter{Tags: []string{"frontend"}}, name: "API_KEY", expected: false},
+		"tag and not in only": {filter: genv.EnvFilter{Only: []string{"API_URL"}, Tags: []string{"frontend"}}, name: "API_KEY", tags: []string{"frontend"}, expected: false},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, tt.filter.Match(tt.name, tt.tags))
+		})
+	}
+}
+
+func TestDotenvGenerator_FetchSecrets_Filter(t *testing.T) {
+	t.Parallel()
+
+	config := &genv.Config{
+		Envs: map[string]genv.EnvValue{
+			"API_URL":     {Value: "https://api.example.com", Tags: []string{"frontend"}},
+			"API_KEY":     {SecretRef: &genv.SecretRef{Provider: "example-account", Key: "api-key"}, Tags: []string{"frontend", "backend"}},
+			"DB_PASSWORD": {SecretRef: &genv.SecretRef{Provider: "example-account", Key: "db-password"}, Tags: []string{"backend"}},
+			"DB_URL":      {Template: "postgres://{{ .Env.DB_USER }}:{{ .Env.DB_PASSWORD }}@db", Tags: []string{"backend"}},
+		},
+		EnvFrom: []genv.EnvFromSource{
+			{
+				SecretRef: &genv.SecretRef{Provider: "example-account", Key: "db-credentials"},
+				Prefix:    "DB_",
+				KeyCase:   genv.KeyCaseUpper,
+				Include:   []string{"user", "host"},
+			},
+			{
+				SecretRef: &genv.SecretRef{Provider: "example-account", Key: "redis-credentials"},
+				Prefix:    "REDIS_",
+				KeyCase:   genv.KeyCaseUpper,
+				Tags:      []string{"cache"},
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		filter          genv.EnvFilter
+		expected        map[string]string
+		expectedFetched []string
+	}{
+		"zero filter": {
+			filter: genv.EnvFilter{},
+			expected: map[string]string{
+				"API_URL":     "https://api.example.com",
+				"API_KEY":     "api-key-value",
+				"DB_PASSWORD": "pass",
+				"DB_URL":      "postgres://user:pass@db",
+				"DB_USER":     "user",
+				"DB_HOST":     "db.example.com",
+				"REDIS_HOST":  "redis.example.com",
+			},
+			expectedFetched: []string{"api-key", "db-credentials", "db-password", "redis-credentials"},
+		},
+		"only": {
+			filter:          genv.EnvFilter{Only: []string{"API_KEY", "REDIS_HOST"}},
+			expected:        map[string]string{"API_KEY": "api-key-value", "REDIS_HOST": "redis.example.com"},
+			expectedFetched: []string{"api-key", "redis-credentials"},
+		},
+		"exclude": {
+			filter: genv.EnvFilter{Exclude: []string{"DB_PASSWORD", "DB_URL", "DB_USER", "DB_HOST"}},
+			expected: map[string]string{
+				"API_URL":    "https://api.example.com",
+				"API_KEY":    "api-key-value",
+				"REDIS_HOST": "redis.example.com",
+			},
+			expectedFetched: []string{"api-key", "redis-credentials"},
+		},
+		"tag": {
+			filter:          genv.EnvFilter{Tags: []string{"frontend"}},
+			expected:        map[string]string{"API_URL": "https://api.example.com", "API_KEY": "api-key-value"},
+			expectedFetched: []string{"api-key"},
+		},
+		"the envs templates depend on are retrieved but not returned": {
+			filter:          genv.EnvFilter{Only: []string{"DB_URL"}},
+			expected:        map[string]string{"DB_URL": "postgres://user:pass@db"},
+			expectedFetched: []string{"db-credentials", "db-password"},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu      sync.Mutex
+				fetched []string
+			)
+			svc := &genv.SecretProviderService{}
+			svc.AddSecretProviderClient("example-account", &mockSecretClient{
+				getSecretFunc: func(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+					mu.Lock()
+					fetched = append(fetched, ref.Key)
+					mu.Unlock()
+
+					switch ref.Key {
+					case "db-credentials":
+						return []byte(`{"user": "user", "host": "db.example.com"}`), nil
+					case "db-password":
+						return []byte("pass"), nil
+					case "redis-credentials":
+						return []byte(`{"host": "redis.example.com"}`), nil
+					default:
+						return []byte("api-key-value"), nil
+					}
+				},
+			})
+
+			generator := &genv.DotenvGenerator{
+				Config:                config.Filter(tt.filter),
+				SecretProviderService: svc,
+			}
+
+			secrets, err := generator.FetchSecrets(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, secrets)
+
+			sort.Strings(fetched)
+			assert.Equal(t, tt.expectedFetched, fetched)
+		})
+	}
+}
+
+func TestDiffEnvName_Filter(t *testing.T) {
+	t.Parallel()
+
+	config := &genv.Config{
+		Envs: map[string]genv.EnvValue{
+			"API_KEY":     {Value: "api-key-value", Tags: []string{"frontend"}},
+			"DB_PASSWORD": {Value: "pass", Tags: []string{"backend"}},
+		},
+		EnvFrom: []genv.EnvFromSource{
+			{
+				SecretRef: &genv.SecretRef{Provider: "example-account", Key: "redis-credentials"},
+				Prefix:    "REDIS_",
+				Tags:      []string{"backend"},
+			},
+		},
+	}
+	dotenv := map[string]string{
+		"REDIS_HOST":  "redis.example.com",
+		"REMOVED_ENV": "removed-value",
+	}
+
+	testCases := map[string]struct {
+		filter   genv.EnvFilter
+		expected diff.Diff
+	}{
+		"only": {
+			filter: genv.EnvFilter{Only: []string{"API_KEY", "REMOVED_ENV"}},
+			expected: diff.Diff{
+				Added:   map[string]string{"API_KEY": "(value not retrieved)"},
+				Removed: map[string]string{"REMOVED_ENV": "(value not retrieved)"},
+				Changed: map[string]diff.ChangeValue{},
+			},
+		},
+		"tag": {
+			// The tags of the envs that are not defined in the config are
+			// unknown, so they are not reported as removed.
+			filter: genv.EnvFilter{Tags: []string{"backend"}},
+			expected: diff.Diff{
+				Added:   map[string]string{"DB_PASSWORD": "(value not retrieved)"},
+				Removed: map[string]string{},
+				Changed: map[string]diff.ChangeValue{},
+			},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := genv.DiffEnvName(context.Background(), config.Filter(tt.filter), dotenv)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *actual)
+		})
+	}
+}
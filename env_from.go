@@ -0,0 +1,103 @@
+package genv
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mrtc0/genv/provider/secretutil"
+)
+
+// expand converts the top-level fields of the JSON secret into envs.
+func (s EnvFromSource) expand(secret []byte) (map[string]string, error) {
+	fields, err := secretutil.GetFieldsFromJSON(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string, len(fields))
+	for field, value := range fields {
+		if !s.selects(field) {
+			continue
+		}
+
+		name, err := s.envName(field)
+		if err != nil {
+			return nil, err
+		}
+
+		envs[name] = value
+	}
+
+	return envs, nil
+}
+
+// selects reports whether the field is expanded according to the include
+// and exclude lists.
+func (s EnvFromSource) selects(field string) bool {
+	if len(s.Include) > 0 && !slices.Contains(s.Include, field) {
+		return false
+	}
+
+	return !slices.Contains(s.Exclude, field)
+}
+
+// envName returns the name of the env the field is expanded into.
+func (s EnvFromSource) envName(field string) (string, error) {
+	switch s.KeyCase {
+	case "":
+	case KeyCaseUpper:
+		field = strings.ToUpper(field)
+	case KeyCaseLower:
+		field = strings.ToLower(field)
+	default:
+		return "", fmt.Errorf("unsupported keyCase: %s", s.KeyCase)
+	}
+
+	return s.Prefix + field, nil
+}
+
+// definedEnvNames returns the names of the envs the source expands into
+// without retrieving the secret. This is only possible when the fields are
+// listed in Include; otherwise ok is false.
+func (s EnvFromSource) definedEnvNames() (names []string, ok bool) {
+	if len(s.Include) == 0 {
+		return nil, false
+	}
+
+	for _, field := range s.Include {
+		if !s.selects(field) {
+			continue
+		}
+
+		name, err := s.envName(field)
+		if err != nil {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+
+	return names, true
+}
+
+// mayDefine reports whether the env could be expanded from the source,
+// without retrieving the secret.
+func (s EnvFromSource) mayDefine(name string) bool {
+	if names, ok := s.definedEnvNames(); ok {
+		return slices.Contains(names, name)
+	}
+
+	if !strings.HasPrefix(name, s.Prefix) {
+		return false
+	}
+
+	field := strings.TrimPrefix(name, s.Prefix)
+	switch s.KeyCase {
+	case KeyCaseUpper:
+		return field == strings.ToUpper(field)
+	case KeyCaseLower:
+		return field == strings.ToLower(field)
+	default:
+		return true
+	}
+}
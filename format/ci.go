@@ -0,0 +1,120 @@
+package format
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// GitHubActions is the name of the format that writes to the file in
+	// $GITHUB_ENV of GitHub Actions.
+	GitHubActions = "github-actions"
+	// GitLab is the name of the format that writes a dotenv report artifact
+	// of GitLab CI/CD.
+	GitLab = "gitlab"
+)
+
+// gitHubActionsEncoder writes the envs in the syntax of the $GITHUB_ENV file
+// of GitHub Actions, in which multi-line values are written with a random
+// heredoc delimiter. To keep the secrets out of the logs, an ::add-mask::
+// workflow command is written to masks for each secret value before the
+// envs are written.
+type gitHubActionsEncoder struct {
+	masks    io.Writer
+	isSecret func(name string) bool
+}
+
+// NewGitHubActionsEncoder returns the encoder of the github-actions format.
+// The workflow commands that mask the values are written to masks, which
+// should be the standard output of the step. isSecret reports whether the
+// value of the env is masked. If nil, all values are masked.
+func NewGitHubActionsEncoder(masks io.Writer, isSecret func(name string) bool) (Encoder, error) {
+	if masks == nil {
+		return nil, fmt.Errorf("%s: no output for the masks", GitHubActions)
+	}
+
+	return &gitHubActionsEncoder{masks: masks, isSecret: isSecret}, nil
+}
+
+func (e *gitHubActionsEncoder) Encode(w io.Writer, envs map[string]string) error {
+	if err := checkNames(envs, envNamePattern, GitHubActions); err != nil {
+		return err
+	}
+
+	keys := sortedKeys(envs)
+	for _, k := range keys {
+		if e.isSecret != nil && !e.isSecret(k) {
+			continue
+		}
+
+		// Multi-line values are masked line by line, as GitHub Actions
+		// matches the masks against each line of the logs.
+		for _, line := range strings.FieldsFunc(envs[k], isNewline) {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if _, err := fmt.Fprintf(e.masks, "::add-mask::%s\n", escapeWorkflowCommandData(line)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, k := range keys {
+		v := envs[k]
+		if !strings.ContainsAny(v, "\r\n") {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", k, v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		delimiter, err := heredocDelimiter(v)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", k, delimiter, v, delimiter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// heredocDelimiter returns a random delimiter that does not appear in the
+// value, so that the value cannot end the heredoc and inject other envs.
+func heredocDelimiter(value string) (string, error) {
+	for {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+
+		delimiter := "ghadelimiter_" + hex.EncodeToString(b)
+		if !strings.Contains(value, delimiter) {
+			return delimiter, nil
+		}
+	}
+}
+
+// escapeWorkflowCommandData escapes the characters that end or break the
+// data of a workflow command.
+func escapeWorkflowCommandData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func isNewline(r rune) bool {
+	return r == '\n' || r == '\r'
+}
+
+// encodeGitLab writes a dotenv report artifact of GitLab CI/CD, which only
+// supports single-line values without quoting.
+func encodeGitLab(w io.Writer, envs map[string]string) error {
+	if err := checkNames(envs, envNamePattern, GitLab); err != nil {
+		return err
+	}
+
+	return encodeUnquoted(w, envs, GitLab)
+}
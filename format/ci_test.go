@@ -0,0 +1,73 @@
+package format_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/mrtc0/genv/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubActionsEncoder_Encode(t *testing.T) {
+	t.Parallel()
+
+	envs := map[string]string{
+		"APP_ENV":     "development",
+		"API_KEY":     "100%\r",
+		"PRIVATE_KEY": "-----BEGIN KEY-----\nabc\n\n-----END KEY-----",
+	}
+	isSecret := func(name string) bool { return name != "APP_ENV" }
+
+	var envFile, masks bytes.Buffer
+	encoder, err := format.NewGitHubActionsEncoder(&masks, isSecret)
+	require.NoError(t, err)
+	require.NoError(t, encoder.Encode(&envFile, envs))
+
+	assert.Equal(t, `::add-mask::100%25
+::add-mask::-----BEGIN KEY-----
+::add-mask::abc
+::add-mask::-----END KEY-----
+`, masks.String())
+
+	pattern := regexp.MustCompile(`^API_KEY<<(ghadelimiter_[0-9a-f]{32})\n100%\r\n(ghadelimiter_[0-9a-f]{32})\n` +
+		`APP_ENV=development\n` +
+		`PRIVATE_KEY<<(ghadelimiter_[0-9a-f]{32})\n-----BEGIN KEY-----\nabc\n\n-----END KEY-----\n(ghadelimiter_[0-9a-f]{32})\n$`)
+	matches := pattern.FindStringSubmatch(envFile.String())
+	require.NotNil(t, matches, envFile.String())
+	assert.Equal(t, matches[1], matches[2])
+	assert.Equal(t, matches[3], matches[4])
+	assert.NotEqual(t, matches[1], matches[3])
+}
+
+func TestGitHubActionsEncoder_Encode_InvalidName(t *testing.T) {
+	t.Parallel()
+
+	encoder, err := format.NewGitHubActionsEncoder(&bytes.Buffer{}, nil)
+	require.NoError(t, err)
+
+	err = encoder.Encode(&bytes.Buffer{}, map[string]string{"KEY=INJECTED": "value"})
+	require.EqualError(t, err, `github-actions: invalid name "KEY=INJECTED"`)
+}
+
+func TestNewGitHubActionsEncoder_WithoutMasks(t *testing.T) {
+	t.Parallel()
+
+	_, err := format.NewGitHubActionsEncoder(nil, nil)
+	require.EqualError(t, err, "github-actions: no output for the masks")
+}
+
+func TestGitLab(t *testing.T) {
+	t.Parallel()
+
+	encoder, err := format.Lookup(format.GitLab)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, encoder.Encode(&buf, map[string]string{"A": "a b", "B": `"quoted"`}))
+	assert.Equal(t, "A=a b\nB=\"quoted\"\n", buf.String())
+
+	err = encoder.Encode(&bytes.Buffer{}, map[string]string{"A": "line 1\nline 2"})
+	require.EqualError(t, err, "gitlab: the value of A contains a newline, which the env file cannot represent")
+}
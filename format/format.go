@@ -0,0 +1,222 @@
+// Package format encodes the generated envs in the formats genv gen can
+// write, such as dotenv, JSON and shell scripts.
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mrtc0/genv/dotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Encoder writes envs in a format.
+type Encoder interface {
+	Encode(w io.Writer, envs map[string]string) error
+}
+
+// EncoderFunc is an adapter to use a function as an Encoder.
+type EncoderFunc func(w io.Writer, envs map[string]string) error
+
+func (f EncoderFunc) Encode(w io.Writer, envs map[string]string) error {
+	return f(w, envs)
+}
+
+// Dotenv is the name of the default format.
+const Dotenv = "dotenv"
+
+var encoders = map[string]Encoder{
+	Dotenv:        EncoderFunc(encodeDotenv),
+	"json":        EncoderFunc(encodeJSON),
+	"yaml":        EncoderFunc(encodeYAML),
+	"shell":       EncoderFunc(encodePOSIXShell),
+	"bash":        EncoderFunc(encodePOSIXShell),
+	"zsh":         EncoderFunc(encodePOSIXShell),
+	"fish":        EncoderFunc(encodeFish),
+	"docker":      EncoderFunc(encodeDocker),
+	"systemd":     EncoderFunc(encodeSystemd),
+	"tfvars.json": EncoderFunc(encodeTFVarsJSON),
+	GitLab:        EncoderFunc(encodeGitLab),
+}
+
+// constructors holds the name of the constructor of each format whose
+// encoder needs options, and is not returned by Lookup.
+var constructors = map[string]string{
+	KubernetesSecret: "NewKubernetesSecretEncoder",
+	GitHubActions:    "NewGitHubActionsEncoder",
+}
+
+// Names returns the names of the supported formats in sorted order.
+func Names() []string {
+	names := slices.AppendSeq(slices.Collect(maps.Keys(encoders)), maps.Keys(constructors))
+	slices.Sort(names)
+
+	return names
+}
+
+// CheckName returns an error if the format is not supported.
+func CheckName(name string) error {
+	if _, ok := encoders[name]; ok {
+		return nil
+	}
+	if _, ok := constructors[name]; ok {
+		return nil
+	}
+
+	return fmt.Errorf("unsupported format: %s (supported formats: %s)", name, strings.Join(Names(), ", "))
+}
+
+// Lookup returns the encoder of a format that needs no options. The
+// encoders of the other formats are created with their constructors, such
+// as NewKubernetesSecretEncoder.
+func Lookup(name string) (Encoder, error) {
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+
+	e, ok := encoders[name]
+	if !ok {
+		return nil, fmt.Errorf("format %s needs options, create the encoder with %s", name, constructors[name])
+	}
+
+	return e, nil
+}
+
+var (
+	// envNamePattern matches the names shells and systemd accept.
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// tfVariablePattern matches the names Terraform accepts for variables.
+	tfVariablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+func sortedKeys(envs map[string]string) []string {
+	return slices.Sorted(maps.Keys(envs))
+}
+
+func checkNames(envs map[string]string, pattern *regexp.Regexp, format string) error {
+	for _, k := range sortedKeys(envs) {
+		if !pattern.MatchString(k) {
+			return fmt.Errorf("%s: invalid name %q", format, k)
+		}
+	}
+
+	return nil
+}
+
+func encodeDotenv(w io.Writer, envs map[string]string) error {
+	content, err := dotenv.Marshal(envs)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, content+"\n")
+	return err
+}
+
+func encodeJSON(w io.Writer, envs map[string]string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(envs)
+}
+
+func encodeYAML(w io.Writer, envs map[string]string) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(envs); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// encodePOSIXShell writes export statements for POSIX shells such as bash
+// and zsh, to be evaluated with eval or source.
+func encodePOSIXShell(w io.Writer, envs map[string]string) error {
+	if err := checkNames(envs, envNamePattern, "shell"); err != nil {
+		return err
+	}
+
+	for _, k := range sortedKeys(envs) {
+		// Nothing is special in single quotes except the single quote itself.
+		v := "'" + strings.ReplaceAll(envs[k], "'", `'\''`) + "'"
+		if _, err := fmt.Fprintf(w, "export %s=%s\n", k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeFish(w io.Writer, envs map[string]string) error {
+	if err := checkNames(envs, envNamePattern, "fish"); err != nil {
+		return err
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	for _, k := range sortedKeys(envs) {
+		if _, err := fmt.Fprintf(w, "set -gx %s '%s'\n", k, replacer.Replace(envs[k])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeDocker writes a file for docker run --env-file, which takes each
+// line as it is, without quoting or escaping.
+func encodeDocker(w io.Writer, envs map[string]string) error {
+	return encodeUnquoted(w, envs, "docker")
+}
+
+// encodeUnquoted writes the envs as KEY=value lines without quoting.
+func encodeUnquoted(w io.Writer, envs map[string]string, format string) error {
+	for _, k := range sortedKeys(envs) {
+		if strings.ContainsAny(k, "=\n") {
+			return fmt.Errorf("%s: invalid name %q", format, k)
+		}
+		if strings.ContainsAny(envs[k], "\r\n") {
+			return fmt.Errorf("%s: the value of %s contains a newline, which the env file cannot represent", format, k)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", k, envs[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeSystemd writes a file for the EnvironmentFile= setting of systemd
+// units. Values are double-quoted, in which systemd treats a backslash as
+// escaping the next character.
+func encodeSystemd(w io.Writer, envs map[string]string) error {
+	if err := checkNames(envs, envNamePattern, "systemd"); err != nil {
+		return err
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	for _, k := range sortedKeys(envs) {
+		if _, err := fmt.Fprintf(w, "%s=\"%s\"\n", k, replacer.Replace(envs[k])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeTFVarsJSON writes a Terraform variable definitions file. The env
+// names are used as the variable names as they are.
+func encodeTFVarsJSON(w io.Writer, envs map[string]string) error {
+	if err := checkNames(envs, tfVariablePattern, "tfvars.json"); err != nil {
+		return err
+	}
+
+	return encodeJSON(w, envs)
+}
@@ -0,0 +1,147 @@
+package format_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mrtc0/genv/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder_Encode(t *testing.T) {
+	t.Parallel()
+
+	envs := map[string]string{
+		"PLAIN":  "value",
+		"QUOTED": `it's "quoted" \ $HOME` + "`cmd`",
+	}
+
+	testCases := map[string]struct {
+		format   string
+		envs     map[string]string
+		expected string
+		errMsg   string
+	}{
+		"dotenv": {
+			format:   "dotenv",
+			envs:     envs,
+			expected: "PLAIN=\"value\"\nQUOTED=\"it's \\\"quoted\\\" \\\\ \\$HOME`cmd`\"\n",
+		},
+		"json": {
+			format: "json",
+			envs:   envs,
+			expected: `{
+  "PLAIN": "value",
+  "QUOTED": "it's \"quoted\" \\ $HOME` + "`cmd`" + `"
+}
+`,
+		},
+		"yaml": {
+			format:   "yaml",
+			envs:     map[string]string{"PLAIN": "value", "NUMBER": "42"},
+			expected: "NUMBER: \"42\"\nPLAIN: value\n",
+		},
+		"shell": {
+			format:   "shell",
+			envs:     envs,
+			expected: "export PLAIN='value'\nexport QUOTED='it'\\''s \"quoted\" \\ $HOME`cmd`'\n",
+		},
+		"shell with invalid name": {
+			format: "bash",
+			envs:   map[string]string{"INVALID-NAME": "value"},
+			errMsg: `shell: invalid name "INVALID-NAME"`,
+		},
+		"fish": {
+			format:   "fish",
+			envs:     envs,
+			expected: "set -gx PLAIN 'value'\nset -gx QUOTED 'it\\'s \"quoted\" \\\\ $HOME`cmd`'\n",
+		},
+		"docker": {
+			format:   "docker",
+			envs:     envs,
+			expected: "PLAIN=value\nQUOTED=it's \"quoted\" \\ $HOME`cmd`\n",
+		},
+		"docker with multi-line value": {
+			format: "docker",
+			envs:   map[string]string{"MULTILINE": "line 1\nline 2"},
+			errMsg: "docker: the value of MULTILINE contains a newline, which the env file cannot represent",
+		},
+		"systemd": {
+			format:   "systemd",
+			envs:     envs,
+			expected: "PLAIN=\"value\"\nQUOTED=\"it's \\\"quoted\\\" \\\\ \\$HOME\\`cmd\\`\"\n",
+		},
+		"tfvars.json": {
+			format:   "tfvars.json",
+			envs:     map[string]string{"db_password": "secret"},
+			expected: "{\n  \"db_password\": \"secret\"\n}\n",
+		},
+		"tfvars.json with invalid name": {
+			format: "tfvars.json",
+			envs:   map[string]string{"1ST": "value"},
+			errMsg: `tfvars.json: invalid name "1ST"`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoder, err := format.Lookup(tt.format)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = encoder.Encode(&buf, tt.envs)
+			if tt.errMsg != "" {
+				require.EqualError(t, err, tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestLookup_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		format string
+		errMsg string
+	}{
+		"unsupported": {
+			format: "xml",
+			errMsg: "unsupported format: xml",
+		},
+		"k8s-secret needs options": {
+			format: format.KubernetesSecret,
+			errMsg: "format k8s-secret needs options, create the encoder with NewKubernetesSecretEncoder",
+		},
+		"github-actions needs options": {
+			format: format.GitHubActions,
+			errMsg: "format github-actions needs options, create the encoder with NewGitHubActionsEncoder",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := format.Lookup(tt.format)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range format.Names() {
+		require.NoError(t, format.CheckName(name), name)
+	}
+	require.EqualError(t, format.CheckName("xml"), "unsupported format: xml (supported formats: "+strings.Join(format.Names(), ", ")+")")
+}
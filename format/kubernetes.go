@@ -0,0 +1,119 @@
+package format
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubernetesSecret is the name of the format that writes a Kubernetes Secret
+// manifest.
+const KubernetesSecret = "k8s-secret"
+
+// KubernetesSecretOptions configures the encoder of the k8s-secret format.
+type KubernetesSecretOptions struct {
+	// Name is the name of the Secret.
+	Name      string
+	Namespace string
+	Labels    map[string]string
+	// ConfigMap is the name of the ConfigMap. If set, the envs for which
+	// IsSecret returns false are put in the ConfigMap instead of the Secret.
+	ConfigMap string
+	IsSecret  func(name string) bool
+}
+
+// kubernetesSecretEncoder writes the envs as a Kubernetes Secret manifest,
+// optionally putting the envs that are not secret in a ConfigMap.
+type kubernetesSecretEncoder struct {
+	KubernetesSecretOptions
+}
+
+// NewKubernetesSecretEncoder returns the encoder of the k8s-secret format.
+// It returns an error if the options are invalid, e.g. the name of the
+// Secret is missing.
+func NewKubernetesSecretEncoder(opts KubernetesSecretOptions) (Encoder, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	opts.Labels = maps.Clone(opts.Labels)
+	return &kubernetesSecretEncoder{opts}, nil
+}
+
+type kubernetesManifest struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   kubernetesMetadata `yaml:"metadata"`
+	Type       string             `yaml:"type,omitempty"`
+	Data       map[string]string  `yaml:"data"`
+}
+
+type kubernetesMetadata struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+var (
+	// kubernetesNamePattern matches DNS subdomain names, which Kubernetes
+	// requires for the names of Secrets and ConfigMaps.
+	kubernetesNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	// kubernetesKeyPattern matches the keys allowed in the data of Secrets
+	// and ConfigMaps.
+	kubernetesKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+func (e *kubernetesSecretEncoder) Encode(w io.Writer, envs map[string]string) error {
+	if err := checkNames(envs, kubernetesKeyPattern, KubernetesSecret); err != nil {
+		return err
+	}
+
+	metadata := kubernetesMetadata{Namespace: e.Namespace, Labels: e.Labels}
+
+	secretData := make(map[string]string)
+	configMapData := make(map[string]string)
+	for k, v := range envs {
+		if e.ConfigMap != "" && e.IsSecret != nil && !e.IsSecret(k) {
+			configMapData[k] = v
+			continue
+		}
+		secretData[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	secret := kubernetesManifest{APIVersion: "v1", Kind: "Secret", Metadata: metadata, Type: "Opaque", Data: secretData}
+	secret.Metadata.Name = e.Name
+	if err := enc.Encode(secret); err != nil {
+		return err
+	}
+
+	if e.ConfigMap != "" {
+		configMap := kubernetesManifest{APIVersion: "v1", Kind: "ConfigMap", Metadata: metadata, Data: configMapData}
+		configMap.Metadata.Name = e.ConfigMap
+		if err := enc.Encode(configMap); err != nil {
+			return err
+		}
+	}
+
+	return enc.Close()
+}
+
+func (o KubernetesSecretOptions) validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("%s: the name of the Secret is required", KubernetesSecret)
+	}
+
+	for _, name := range []string{o.Name, o.ConfigMap} {
+		if name != "" && !kubernetesNamePattern.MatchString(name) {
+			return fmt.Errorf("%s: invalid name %q, must be a lowercase RFC 1123 subdomain", KubernetesSecret, name)
+		}
+	}
+
+	return nil
+}
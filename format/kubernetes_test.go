@@ -0,0 +1,94 @@
+package format_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mrtc0/genv/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesSecretEncoder_Encode(t *testing.T) {
+	t.Parallel()
+
+	envs := map[string]string{
+		"APP_ENV": "development",
+		"API_KEY": "secret",
+	}
+	isSecret := func(name string) bool { return name == "API_KEY" }
+
+	testCases := map[string]struct {
+		opts     format.KubernetesSecretOptions
+		expected string
+		errMsg   string
+	}{
+		"secret": {
+			opts: format.KubernetesSecretOptions{
+				Name:      "app-env",
+				Namespace: "dev",
+				Labels:    map[string]string{"app": "web"},
+			},
+			expected: `apiVersion: v1
+kind: Secret
+metadata:
+  name: app-env
+  namespace: dev
+  labels:
+    app: web
+type: Opaque
+data:
+  API_KEY: c2VjcmV0
+  APP_ENV: ZGV2ZWxvcG1lbnQ=
+`,
+		},
+		"secret and configmap": {
+			opts: format.KubernetesSecretOptions{
+				Name:      "app-env",
+				ConfigMap: "app-config",
+				IsSecret:  isSecret,
+			},
+			expected: `apiVersion: v1
+kind: Secret
+metadata:
+  name: app-env
+type: Opaque
+data:
+  API_KEY: c2VjcmV0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  APP_ENV: development
+`,
+		},
+		"without name": {
+			opts:   format.KubernetesSecretOptions{},
+			errMsg: "k8s-secret: the name of the Secret is required",
+		},
+		"invalid name": {
+			opts:   format.KubernetesSecretOptions{Name: "App_Env"},
+			errMsg: `k8s-secret: invalid name "App_Env", must be a lowercase RFC 1123 subdomain`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoder, err := format.NewKubernetesSecretEncoder(tt.opts)
+			if tt.errMsg != "" {
+				require.EqualError(t, err, tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, encoder.Encode(&buf, envs))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
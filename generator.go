@@ -2,7 +2,16 @@ package genv
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultConcurrency is the default maximum number of secrets fetched concurrently.
+	DefaultConcurrency = 10
 )
 
 type DotenvGeneratorConfig struct {
@@ -29,30 +38,205 @@ func NewDotenvGenerator(ctx context.Context, config DotenvGeneratorConfig) (*Dot
 	}, nil
 }
 
+// Close releases the secret provider clients. It must be called when the
+// generator is no longer used.
+func (d *DotenvGenerator) Close() error {
+	return d.SecretProviderService.Close()
+}
+
+// FetchSecrets resolves all envs defined in the config, including the envs
+// expanded from EnvFrom. Secrets are fetched concurrently up to
+// Config.Concurrency at a time, and envs that refer to the same secret share
+// a single fetch. If any secret cannot be fetched, all failures are returned
+// together, in a deterministic order.
+//
+// Envs defined by a template are rendered once all other envs are resolved,
+// in the order of their dependencies on each other.
+//
+// If the config was narrowed down with Config.Filter, only the selected envs
+// are returned.
 func (d *DotenvGenerator) FetchSecrets(ctx context.Context) (map[string]string, error) {
-	envMap := make(map[string]string)
+	ctx = WithSecretCache(ctx)
+
+	keys := make([]string, 0, len(d.Config.Envs))
+	for key := range d.Config.Envs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		tasks         []func(ctx context.Context) error
+		envFromValues = make([]map[string]string, len(d.Config.EnvFrom))
+		values        = make([]string, len(keys))
+		templates     = make(map[string]*templateEnv)
+		funcs         = d.templateFuncs(ctx)
+	)
+
+	for i, source := range d.Config.EnvFrom {
+		tasks = append(tasks, func(ctx context.Context) error {
+			envs, err := d.fetchEnvFrom(ctx, source)
+			if err != nil {
+				return fmt.Errorf("failed to expand envFrom[%d]%s: %w", i, describeSources(d.Config.envFromSources(i)...), err)
+			}
+
+			envFromValues[i] = envs
+			return nil
+		})
+	}
+
+	for i, key := range keys {
+		envValue := d.Config.Envs[key]
 
-	for key, envValue := range d.Config.Envs {
 		if envValue.Value != "" {
-			envMap[key] = envValue.Value
+			values[i] = envValue.Value
 			continue
 		}
 
 		if envValue.SecretRef != nil {
-			secret, err := d.SecretProviderService.GetSecret(ctx, envValue.SecretRef.Provider, GetSecretInput{
-				Key:      envValue.SecretRef.Key,
-				Property: envValue.SecretRef.Property,
+			ref := envValue.SecretRef
+			tasks = append(tasks, func(ctx context.Context) error {
+				secret, err := d.SecretProviderService.GetSecret(ctx, ref.Provider, GetSecretInput{
+					Key:      ref.Key,
+					Property: ref.Property,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to get secret %s%s: %w", key, describeSources(d.Config.envSources(key)...), err)
+				}
+
+				values[i] = string(secret)
+				return nil
 			})
+			continue
+		}
 
+		if envValue.Template != "" {
+			t, err := parseTemplateEnv(key, envValue.Template, funcs)
 			if err != nil {
-				return nil, fmt.Errorf("failed to get secret %s: %w", key, err)
+				return nil, fmt.Errorf("failed to parse template %s%s: %w", key, describeSources(d.Config.envSources(key)...), err)
+			}
+			templates[key] = t
+
+			// Warm the secret cache so that the secrets referenced by templates
+			// are fetched concurrently with the others.
+			for _, ref := range t.secrets {
+				tasks = append(tasks, func(ctx context.Context) error {
+					if _, err := d.SecretProviderService.GetSecret(ctx, ref.Provider, GetSecretInput{Key: ref.Key}); err != nil {
+						return fmt.Errorf("failed to get secret for template %s%s: %w", key, describeSources(d.Config.envSources(key)...), err)
+					}
+					return nil
+				})
 			}
+		}
+	}
+
+	order, err := sortTemplateEnvs(templates)
+	if err != nil {
+		return nil, err
+	}
 
-			envMap[key] = string(secret)
+	if err := runConcurrently(ctx, d.Config.Concurrency, tasks); err != nil {
+		return nil, err
+	}
+
+	envMap := make(map[string]string, len(keys))
+	tags := make(map[string][]string)
+	for i, envs := range envFromValues {
+		for key, value := range envs {
+			envMap[key] = value
+			tags[key] = d.Config.EnvFrom[i].Tags
+		}
+	}
+	for _, key := range keys {
+		tags[key] = d.Config.Envs[key].Tags
+	}
+	for i, key := range keys {
+		if _, ok := templates[key]; ok {
 			continue
 		}
+		envMap[key] = values[i]
+	}
+
+	// Templates are rendered after all other envs are resolved, in
+	// dependency order.
+	var errs []error
+	for _, name := range order {
+		t := templates[name]
 
-		envMap[key] = ""
+		var undefined []string
+		for _, dep := range t.deps {
+			if _, ok := envMap[dep]; !ok {
+				undefined = append(undefined, dep)
+			}
+		}
+		if len(undefined) > 0 {
+			errs = append(errs, fmt.Errorf("failed to render template %s%s: undefined env %s", name, describeSources(d.Config.envSources(name)...), strings.Join(undefined, ", ")))
+			continue
+		}
+
+		value, err := t.render(envMap)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to render template %s%s: %w", name, describeSources(d.Config.envSources(name)...), err))
+			continue
+		}
+
+		envMap[name] = value
 	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	// Drop the envs only resolved to render the templates of selected envs.
+	for key := range envMap {
+		if !d.Config.selects(key, tags[key]) {
+			delete(envMap, key)
+		}
+	}
+
 	return envMap, nil
 }
+
+func (d *DotenvGenerator) fetchEnvFrom(ctx context.Context, source EnvFromSource) (map[string]string, error) {
+	if source.SecretRef == nil {
+		return nil, errors.New("secretRef is required")
+	}
+
+	secret, err := d.SecretProviderService.GetSecret(ctx, source.SecretRef.Provider, GetSecretInput{
+		Key:      source.SecretRef.Key,
+		Property: source.SecretRef.Property,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", source.SecretRef.Key, err)
+	}
+
+	return source.expand(secret)
+}
+
+// runConcurrently runs the tasks concurrently, with at most limit secrets
+// fetched at a time, or DefaultConcurrency if limit is not positive. The
+// limit is taken by SecretProviderService.GetSecret after the limit of the
+// provider. All tasks are run even if some of them fail, and the errors are
+// joined in the order of the tasks.
+func runConcurrently(ctx context.Context, limit int, tasks []func(ctx context.Context) error) error {
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+	ctx = withConcurrencyLimit(ctx, limit)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(tasks))
+	)
+
+	for i, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			errs[i] = task(ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package fileutil
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// chown changes the owner and group of f to those of the existing file, if
+// they differ. Only root may give a file away, so f keeps the owner of the
+// user writing it if it is not permitted.
+func chown(f *os.File, existing os.FileInfo) error {
+	stat, ok := existing.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if current, ok := info.Sys().(*syscall.Stat_t); ok && current.Uid == stat.Uid && current.Gid == stat.Gid {
+		return nil
+	}
+
+	if err := f.Chown(int(stat.Uid), int(stat.Gid)); err != nil && !errors.Is(err, syscall.EPERM) {
+		return err
+	}
+
+	return nil
+}
+
+// syncDir flushes the directory entry of a renamed file to disk. Errors are
+// ignored since the file itself has already been written.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+
+	_ = d.Sync()
+}
@@ -0,0 +1,11 @@
+package fileutil
+
+import "os"
+
+// Files on Windows have no Unix ownership to keep.
+func chown(f *os.File, existing os.FileInfo) error {
+	return nil
+}
+
+// Directories cannot be synced on Windows.
+func syncDir(dir string) {}
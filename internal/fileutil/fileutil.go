@@ -0,0 +1,144 @@
+// Package fileutil provides the file operations shared by the output formats.
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultFileMode is the mode of the files created by WriteFile.
+const DefaultFileMode os.FileMode = 0600
+
+type WriteOptions struct {
+	// Mode is the mode of the file. If zero, the mode of the existing file is
+	// kept, and new files are created with DefaultFileMode.
+	Mode os.FileMode
+	// FollowSymlinks makes WriteFile write to the target of the file if it
+	// is a symbolic link. Otherwise, WriteFile refuses to write to it.
+	FollowSymlinks bool
+}
+
+// WriteFile writes the content to the file atomically: the content is
+// written to a temporary file in the same directory, which then replaces the
+// file. Either the old or the new content is left even if writing fails. The
+// mode and ownership of the existing file are kept.
+func WriteFile(filename string, content []byte, o WriteOptions) error {
+	filename, info, err := resolve(filename, o)
+	if err != nil {
+		return err
+	}
+
+	mode := DefaultFileMode
+	switch {
+	case o.Mode != 0:
+		mode = o.Mode
+	case info != nil:
+		mode = info.Mode().Perm()
+	}
+
+	return writeFileAtomic(filename, content, mode, info)
+}
+
+// AppendFile appends the content to the file, creating it if it does not
+// exist. o.Mode is only applied to new files.
+func AppendFile(filename string, content []byte, o WriteOptions) error {
+	filename, _, err := resolve(filename, o)
+	if err != nil {
+		return err
+	}
+
+	mode := DefaultFileMode
+	if o.Mode != 0 {
+		mode = o.Mode
+	}
+
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// resolve returns the file to write to and its FileInfo, which is nil if the
+// file does not exist. It fails if the file is not a regular file, or is a
+// symbolic link and o.FollowSymlinks is not set.
+func resolve(filename string, o WriteOptions) (string, os.FileInfo, error) {
+	info, err := os.Lstat(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return filename, nil, nil
+	case err != nil:
+		return "", nil, err
+	case info.Mode()&os.ModeSymlink != 0:
+		if !o.FollowSymlinks {
+			return "", nil, fmt.Errorf("refusing to write to %s: it is a symbolic link", filename)
+		}
+
+		if filename, err = filepath.EvalSymlinks(filename); err != nil {
+			return "", nil, err
+		}
+		if info, err = os.Stat(filename); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", nil, fmt.Errorf("refusing to write to %s: it is not a regular file", filename)
+	}
+
+	return filename, info, nil
+}
+
+func writeFileAtomic(filename string, content []byte, mode os.FileMode, existing os.FileInfo) (err error) {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if existing != nil {
+		if err := chown(tmp, existing); err != nil {
+			return fmt.Errorf("failed to keep the ownership of %s: %w", filename, err)
+		}
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+
+	return nil
+}
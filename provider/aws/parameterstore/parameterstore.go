@@ -0,0 +1,59 @@
+package parameterstore
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/secretutil"
+)
+
+var _ provider.SecretClient = &ParameterStore{}
+
+// ParameterStore is a client for AWS Systems Manager Parameter Store.
+type ParameterStore struct {
+	client *ssm.Client
+}
+
+// NewParameterStore creates a new ParameterStore client.
+func NewParameterStore(cfg aws.Config) *ParameterStore {
+	client := ssm.NewFromConfig(cfg)
+	return &ParameterStore{
+		client: client,
+	}
+}
+
+// GetSecret retrieves a parameter from AWS Systems Manager Parameter Store.
+// SecureString parameters are decrypted.
+func (p *ParameterStore) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+	value, err := p.fetch(ctx, ref.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.Property == "" {
+		return value, nil
+	}
+
+	val, err := secretutil.GetValueFromJSON(value, ref.Property)
+	if err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+func (p *ParameterStore) fetch(ctx context.Context, name string) ([]byte, error) {
+	input := &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	}
+
+	result, err := p.client.GetParameter(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(aws.ToString(result.Parameter.Value)), nil
+}
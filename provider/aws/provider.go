@@ -8,23 +8,28 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/aws/parameterstore"
 	"github.com/mrtc0/genv/provider/aws/secretsmanager"
 )
 
-type awsSecretService string
+type AwsSecretService string
 
 const (
-	AWSSecretsManager awsSecretService = "SecretsManager"
+	AWSSecretsManager AwsSecretService = "SecretsManager"
+	SSMParameterStore AwsSecretService = "ParameterStore"
 )
 
 var _ provider.Provider = &Provider{}
 
 type AwsProviderConfig struct {
 	ID       string           `yaml:"id"`
-	Service  awsSecretService `yaml:"service"`
+	Service  AwsSecretService `yaml:"service"`
 	Region   string           `yaml:"region,omitempty"`
 	Endpoint string           `yaml:"endpoint,omitempty"`
 	Auth     AwsAuth          `yaml:"auth,omitempty"`
+	// DisableRetries turns off the retries of the SDK, for the callers
+	// that retry the requests themselves.
+	DisableRetries bool `yaml:"-"`
 }
 
 type AwsAuth struct {
@@ -57,10 +62,13 @@ func newClient(ctx context.Context, providerConfig *AwsProviderConfig) (provider
 	}
 
 	switch providerConfig.Service {
-	case AWSSecretsManager:
+	// Secrets Manager is used when the service is omitted for backward compatibility.
+	case AWSSecretsManager, "":
 		return secretsmanager.NewSecretsManager(cfg), nil
+	case SSMParameterStore:
+		return parameterstore.NewParameterStore(cfg), nil
 	default:
-		return nil, errors.New("unsupported AWS service")
+		return nil, errors.New("unsupported AWS service: " + string(providerConfig.Service))
 	}
 }
 
@@ -96,5 +104,9 @@ func loadOptions(c *AwsProviderConfig) []func(*config.LoadOptions) error {
 		loadOptions = append(loadOptions, config.WithBaseEndpoint(c.Endpoint))
 	}
 
+	if c.DisableRetries {
+		loadOptions = append(loadOptions, config.WithRetryer(func() aws.Retryer { return aws.NopRetryer{} }))
+	}
+
 	return loadOptions
 }
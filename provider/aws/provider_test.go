@@ -194,3 +194,38 @@ func TestNewClient(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, client)
 }
+
+func TestGetAWSConfig_Retryer(t *testing.T) {
+	mocks.InitSessionTestEnv(t)
+
+	testCases := map[string]struct {
+		disableRetries bool
+		// expectedMaxAttempts is zero if the clients use the default
+		// retryer of the SDK.
+		expectedMaxAttempts int
+	}{
+		"retries of the SDK": {},
+		"retries disabled": {
+			disableRetries:      true,
+			expectedMaxAttempts: 1,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := aws.GetAWSConfig(context.Background(), &aws.AwsProviderConfig{
+				ID:             "aws",
+				Region:         "ap-northeast-1",
+				DisableRetries: tt.disableRetries,
+			})
+			require.NoError(t, err)
+			if tt.expectedMaxAttempts == 0 {
+				assert.Nil(t, cfg.Retryer)
+				return
+			}
+
+			require.NotNil(t, cfg.Retryer)
+			assert.Equal(t, tt.expectedMaxAttempts, cfg.Retryer().MaxAttempts())
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package exec
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group, and kills the
+// whole group on cancel so that the processes started by a shell die with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// The command is the leader of its process group, so the group ID
+		// is the PID of the command.
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
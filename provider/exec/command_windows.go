@@ -0,0 +1,5 @@
+package exec
+
+import "os/exec"
+
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -3,14 +3,20 @@ package exec
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"sync"
+	"time"
 
 	"github.com/mrtc0/genv/provider"
 	"github.com/mrtc0/genv/provider/secretutil"
 )
 
+// waitDelay is how long a killed command may keep its output open, e.g.
+// through the processes it started, before the output is closed.
+const waitDelay = time.Second
+
 var _ provider.Provider = &Provider{}
 var _ provider.SecretClient = &Client{}
 
@@ -39,7 +45,9 @@ func (p *Provider) NewClient(ctx context.Context) (provider.SecretClient, error)
 // Client executes the configured command once and caches the JSON output.
 type Client struct {
 	command []string
-	once    sync.Once
+
+	mu      sync.Mutex
+	done    bool
 	output  []byte
 	execErr error
 }
@@ -48,14 +56,12 @@ type Client struct {
 // ref.Key using gjson. If ref.Property is also set it is applied as a
 // second gjson path on the result of ref.Key.
 func (c *Client) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
-	c.once.Do(func() {
-		c.output, c.execErr = c.run(ctx)
-	})
-	if c.execErr != nil {
-		return nil, c.execErr
+	output, err := c.outputOf(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	val, err := secretutil.GetValueFromJSON(c.output, ref.Key)
+	val, err := secretutil.GetValueFromJSON(output, ref.Key)
 	if err != nil {
 		return nil, fmt.Errorf("exec provider: key %q not found in output: %w", ref.Key, err)
 	}
@@ -72,8 +78,30 @@ func (c *Client) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte,
 	return val, nil
 }
 
+// outputOf returns the output of the command, which runs on the first call.
+func (c *Client) outputOf(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done {
+		return c.output, c.execErr
+	}
+
+	output, err := c.run(ctx)
+	// The run that was killed because the call was canceled or timed out is
+	// tried again on the next call, with the context of that call.
+	if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return nil, err
+	}
+	c.output, c.execErr, c.done = output, err, true
+
+	return c.output, c.execErr
+}
+
 func (c *Client) run(ctx context.Context) ([]byte, error) {
 	cmd := exec.CommandContext(ctx, c.command[0], c.command[1:]...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
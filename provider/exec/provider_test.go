@@ -2,7 +2,10 @@ package exec_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mrtc0/genv/provider"
 	execprovider "github.com/mrtc0/genv/provider/exec"
@@ -133,3 +136,49 @@ func TestNewClient(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "command must not be empty")
 }
+
+func TestClient_GetSecret_RunsCommandOnce(t *testing.T) {
+	t.Parallel()
+
+	// The command appends to a file each time it runs, so that we can
+	// verify it is executed only once for all keys.
+	counter := filepath.Join(t.TempDir(), "counter")
+	cfg := &execprovider.ExecProviderConfig{
+		ID:      "test",
+		Command: []string{"sh", "-c", `echo run >> "$0"; echo '{"a":"1","b":"2"}'`, counter},
+	}
+	client, err := execprovider.NewProvider(cfg).NewClient(context.Background())
+	require.NoError(t, err)
+
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		got, err := client.GetSecret(context.Background(), provider.SecretRef{Key: key})
+		require.NoError(t, err)
+		assert.Equal(t, []byte(want), got)
+	}
+
+	runs, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(runs))
+}
+
+func TestClient_GetSecret_KillsChildProcesses(t *testing.T) {
+	t.Parallel()
+
+	// The shell waits for sleep, which keeps the output open after the shell
+	// is killed.
+	cfg := &execprovider.ExecProviderConfig{
+		ID:      "test",
+		Command: []string{"sh", "-c", `sleep 10; echo '{"a":"1"}'`},
+	}
+	client, err := execprovider.NewProvider(cfg).NewClient(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.GetSecret(ctx, provider.SecretRef{Key: "a"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exec provider: command failed")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/googleapis/gax-go/v2"
 	"github.com/mrtc0/genv/provider"
 	"github.com/mrtc0/genv/provider/googlecloud/secretmanager"
 )
@@ -15,6 +16,9 @@ type GoogleCloudProvider struct {
 	Service   string
 	ProjectID string
 	Location  string
+	// DisableRetries turns off the retries of the client library, for the
+	// callers that retry the requests themselves.
+	DisableRetries bool
 }
 
 type Provider struct {
@@ -37,9 +41,18 @@ func (p *Provider) NewClient(ctx context.Context) (provider.SecretClient, error)
 }
 
 func newClient(ctx context.Context, cfg *GoogleCloudProvider) (provider.SecretClient, error) {
-	return secretmanager.NewSecretManagerClient(
+	client, err := secretmanager.NewSecretManagerClient(
 		ctx,
 		cfg.ProjectID,
 		cfg.Location,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.DisableRetries {
+		client.CallOptions = append(client.CallOptions, gax.WithRetry(nil))
+	}
+
+	return client, nil
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/mrtc0/genv/provider"
 	"github.com/mrtc0/genv/provider/secretutil"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var _ provider.SecretClient = &SecretManagerClient{}
@@ -22,6 +24,8 @@ type SecretManagerClient struct {
 	ProjectID string
 	Location  string
 	Client    SecretManagerClientInterface
+	// CallOptions are passed to each request, e.g. to change the retries.
+	CallOptions []gax.CallOption
 }
 
 func NewSecretManagerClient(ctx context.Context, projectID, location string) (*SecretManagerClient, error) {
@@ -42,8 +46,12 @@ func NewSecretManagerClient(ctx context.Context, projectID, location string) (*S
 func (s *SecretManagerClient) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
 	result, err := s.Client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
 		Name: s.buildResourceName(ref.Key),
-	})
+	}, s.CallOptions...)
 	if err != nil {
+		switch status.Code(err) {
+		case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.Internal:
+			return nil, provider.Transient(err)
+		}
 		return nil, err
 	}
 
@@ -3,6 +3,7 @@ package secretmanager_test
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"testing"
 
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
@@ -157,20 +158,40 @@ func TestGetSecret(t *testing.T) {
 
 			require.NoError(t, err)
 			assert.Equal(t, tc.want.secret, secret)
-			assert.True(t, tc.arrange.mockClient.Called)
+			assert.True(t, tc.arrange.mockClient.Called.Load())
 		})
 	}
 }
 
+func TestGetSecret_CallOptions(t *testing.T) {
+	t.Parallel()
+
+	callOptions := []gax.CallOption{gax.WithRetry(nil)}
+	client := &secretmanager.SecretManagerClient{
+		ProjectID:   dummyProjectID,
+		CallOptions: callOptions,
+		Client: &mockSecretManagerClient{
+			AccessSecretVersionFunc: func(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+				assert.Len(t, opts, len(callOptions))
+				return &secretmanagerpb.AccessSecretVersionResponse{Payload: &secretmanagerpb.SecretPayload{Data: []byte("value")}}, nil
+			},
+		},
+	}
+
+	got, err := client.GetSecret(t.Context(), provider.SecretRef{Key: "my-secret"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), got)
+}
+
 type mockSecretManagerClient struct {
 	AccessSecretVersionFunc func(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
 
-	Called bool
+	Called atomic.Bool
 }
 
 func (m *mockSecretManagerClient) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
 	if m.AccessSecretVersionFunc != nil {
-		m.Called = true
+		m.Called.Store(true)
 		return m.AccessSecretVersionFunc(ctx, req, opts...)
 	}
 
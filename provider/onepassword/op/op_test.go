@@ -3,6 +3,7 @@ package op_test
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 
 	"github.com/mrtc0/genv/provider"
@@ -93,10 +94,10 @@ func TestOPClient_GetSecret(t *testing.T) {
 
 type MockOPCommandExecutor struct {
 	ExecFunc func(ctx context.Context, args []string) ([]byte, error)
-	Called   bool
+	Called   atomic.Bool
 }
 
 func (m *MockOPCommandExecutor) Exec(ctx context.Context, args []string) ([]byte, error) {
-	m.Called = true
+	m.Called.Store(true)
 	return m.ExecFunc(ctx, args)
 }
@@ -2,6 +2,8 @@ package onepassword
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/mrtc0/genv/provider"
 	"github.com/mrtc0/genv/provider/onepassword/op"
@@ -54,3 +56,27 @@ func WithAccount(account string) ProviderOption {
 		p.account = account
 	}
 }
+
+// ValidateSecretReference checks that ref is a secret reference of the form
+// op://<vault>/<item>/[<section>/]<field>, optionally followed by query
+// parameters such as ?attribute=otp.
+func ValidateSecretReference(ref string) error {
+	path, ok := strings.CutPrefix(ref, "op://")
+	if !ok {
+		return fmt.Errorf("secret reference %q must start with op://", ref)
+	}
+
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+	if len(segments) != 3 && len(segments) != 4 {
+		return fmt.Errorf("secret reference %q must be op://<vault>/<item>/[<section>/]<field>", ref)
+	}
+
+	for _, s := range segments {
+		if s == "" {
+			return fmt.Errorf("secret reference %q must not contain empty segments", ref)
+		}
+	}
+
+	return nil
+}
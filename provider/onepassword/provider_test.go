@@ -0,0 +1,43 @@
+package onepassword_test
+
+import (
+	"testing"
+
+	"github.com/mrtc0/genv/provider/onepassword"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSecretReference(t *testing.T) {
+	testCases := map[string]struct {
+		ref    string
+		errMsg string // empty means no error expected
+	}{
+		"field":                {ref: "op://vault/item/field"},
+		"field in section":     {ref: "op://vault/item/section/field"},
+		"with query parameter": {ref: "op://vault/item/one-time password?attribute=otp"},
+		"missing scheme": {
+			ref:    "vault/item/field",
+			errMsg: "must start with op://",
+		},
+		"missing field": {
+			ref:    "op://vault/item",
+			errMsg: "must be op://<vault>/<item>/[<section>/]<field>",
+		},
+		"empty segment": {
+			ref:    "op://vault//field",
+			errMsg: "must not contain empty segments",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := onepassword.ValidateSecretReference(tc.ref)
+			if tc.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
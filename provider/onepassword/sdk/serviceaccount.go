@@ -16,6 +16,11 @@ const (
 var _ provider.SecretClient = &OnePasswordClient{}
 
 type OnePasswordClient struct {
+	// Secrets resolves the secret references.
+	Secrets onepassword.SecretsAPI
+
+	// client is kept so that the SDK does not release it while Secrets is
+	// in use.
 	client *onepassword.Client
 }
 
@@ -31,11 +36,11 @@ func NewOnePasswordClient() (*OnePasswordClient, error) {
 		return nil, err
 	}
 
-	return &OnePasswordClient{client: client}, nil
+	return &OnePasswordClient{Secrets: client.Secrets(), client: client}, nil
 }
 
 func (c *OnePasswordClient) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
-	secret, err := c.client.Secrets().Resolve(ctx, ref.Key)
+	secret, err := c.Secrets.Resolve(ctx, ref.Key)
 	if err != nil {
 		return nil, err
 	}
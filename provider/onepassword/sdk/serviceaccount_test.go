@@ -0,0 +1,69 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/onepassword/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnePasswordClient_GetSecret(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		ref         provider.SecretRef
+		resolveFunc func(ctx context.Context, secretReference string) (string, error)
+		expected    []byte
+		errMsg      string
+	}{
+		"resolve secret reference": {
+			ref: provider.SecretRef{Key: "op://vault/item/field"},
+			resolveFunc: func(ctx context.Context, secretReference string) (string, error) {
+				assert.Equal(t, "op://vault/item/field", secretReference)
+				return "secret-value", nil
+			},
+			expected: []byte("secret-value"),
+		},
+		"resolve error": {
+			ref: provider.SecretRef{Key: "op://vault/item/non-existing"},
+			resolveFunc: func(ctx context.Context, secretReference string) (string, error) {
+				return "", errors.New("field not found")
+			},
+			errMsg: "field not found",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &sdk.OnePasswordClient{Secrets: &mockSecretsAPI{ResolveFunc: tt.resolveFunc}}
+
+			got, err := client.GetSecret(context.Background(), tt.ref)
+			if tt.errMsg != "" {
+				require.EqualError(t, err, tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+type mockSecretsAPI struct {
+	ResolveFunc func(ctx context.Context, secretReference string) (string, error)
+}
+
+func (m *mockSecretsAPI) Resolve(ctx context.Context, secretReference string) (string, error) {
+	return m.ResolveFunc(ctx, secretReference)
+}
+
+func (m *mockSecretsAPI) ResolveAll(ctx context.Context, secretReferences []string) (onepassword.ResolveAllResponse, error) {
+	return onepassword.ResolveAllResponse{}, errors.New("not implemented")
+}
@@ -0,0 +1,221 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/mrtc0/genv/provider"
+)
+
+var _ provider.SecretClient = &Client{}
+
+// maxMessageSize is the maximum size of a response from a plugin.
+const maxMessageSize = 16 << 20
+
+// shutdownTimeout is how long Close waits for the plugin to exit before
+// killing it.
+const shutdownTimeout = 5 * time.Second
+
+// Client is a running plugin.
+type Client struct {
+	name string
+	cmd  *exec.Cmd
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan response
+	// done is closed when the plugin closes its standard output, after
+	// which readErr holds the reason.
+	done    chan struct{}
+	readErr error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Start starts the plugin at path and initializes it with the config.
+func Start(ctx context.Context, path string, config map[string]any) (*Client, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", path, err)
+	}
+
+	c := &Client{
+		name:    path,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan response),
+		done:    make(chan struct{}),
+	}
+	go c.read(stdout)
+
+	var result InitializeResult
+	if err := c.call(ctx, MethodInitialize, InitializeParams{ProtocolVersion: ProtocolVersion, Config: config}, &result); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize plugin %s: %w", path, err)
+	}
+	if result.ProtocolVersion != ProtocolVersion {
+		c.Close()
+		return nil, fmt.Errorf("plugin %s speaks protocol version %d, but version %d is required", path, result.ProtocolVersion, ProtocolVersion)
+	}
+
+	return c, nil
+}
+
+// GetSecret returns the value of the secret identified by ref.Key. The
+// property is extracted by the caller.
+func (c *Client) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+	var result GetSecretResult
+	if err := c.call(ctx, MethodGetSecret, GetSecretParams{Key: ref.Key}, &result); err != nil {
+		return nil, err
+	}
+
+	return []byte(result.Value), nil
+}
+
+// ListSecrets returns the keys of the secrets the plugin provides.
+func (c *Client) ListSecrets(ctx context.Context) ([]string, error) {
+	var result ListSecretsResult
+	if err := c.call(ctx, MethodListSecrets, struct{}{}, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Keys, nil
+}
+
+// Close asks the plugin to shut down and waits for it to exit. The plugin is
+// killed if it does not exit in time.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		// The plugin also exits when its standard input is closed, so the
+		// result of shutdown does not matter.
+		_ = c.call(ctx, MethodShutdown, struct{}{}, nil)
+		c.stdin.Close()
+
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			c.cmd.Process.Kill()
+		}
+
+		if err := c.cmd.Wait(); err != nil {
+			c.closeErr = fmt.Errorf("plugin %s: %w", c.name, err)
+		}
+	})
+
+	return c.closeErr
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan response, 1)
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(request{JSONRPC: "2.0", ID: id, Method: method, Params: rawParams}); err != nil {
+		return fmt.Errorf("plugin %s: %s: %w", c.name, method, err)
+	}
+
+	select {
+	case res := <-ch:
+		if res.Error != nil {
+			return fmt.Errorf("plugin %s: %s: %w", c.name, method, res.Error)
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(res.Result, result); err != nil {
+			return fmt.Errorf("plugin %s: %s: invalid result: %w", c.name, method, err)
+		}
+		return nil
+	case <-c.done:
+		return fmt.Errorf("plugin %s: %s: %w", c.name, method, c.readErr)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) write(req request) error {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err = c.stdin.Write(append(b, '\n'))
+	return err
+}
+
+// read dispatches the responses of the plugin to the pending calls until
+// the plugin closes its standard output.
+func (c *Client) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	var err error
+	for scanner.Scan() {
+		var res response
+		if err = json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			err = fmt.Errorf("invalid response: %w", err)
+			break
+		}
+
+		c.mu.Lock()
+		if ch, ok := c.pending[res.ID]; ok {
+			// Ignore duplicate responses instead of blocking on them.
+			select {
+			case ch <- res:
+			default:
+			}
+		}
+		c.mu.Unlock()
+	}
+	if err == nil {
+		err = scanner.Err()
+	}
+	if err == nil {
+		err = errors.New("plugin exited")
+	}
+
+	c.readErr = err
+	close(c.done)
+}
@@ -0,0 +1,226 @@
+package plugin_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// servePluginEnv makes the test binary run as a plugin, so that the tests
+// can start it with plugin.Start.
+const servePluginEnv = "GENV_TEST_SERVE_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(servePluginEnv) != "" {
+		if err := plugin.Serve(context.Background(), os.Stdin, os.Stdout, &testHandler{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Setenv(servePluginEnv, "1")
+	os.Exit(m.Run())
+}
+
+// testHandler serves the secrets given in the "secrets" field of the config.
+type testHandler struct {
+	secrets map[string]string
+}
+
+func (h *testHandler) Initialize(ctx context.Context, config map[string]any) error {
+	secrets, _ := config["secrets"].(map[string]any)
+	if secrets == nil {
+		return errors.New("secrets is required")
+	}
+
+	h.secrets = make(map[string]string)
+	for k, v := range secrets {
+		h.secrets[k] = fmt.Sprint(v)
+	}
+
+	return nil
+}
+
+func (h *testHandler) GetSecret(ctx context.Context, key string) (string, error) {
+	if key == "exit" {
+		os.Exit(3)
+	}
+
+	v, ok := h.secrets[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", key)
+	}
+
+	return v, nil
+}
+
+func (h *testHandler) ListSecrets(ctx context.Context) ([]string, error) {
+	keys := make([]string, 0, len(h.secrets))
+	for k := range h.secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+var testConfig = map[string]any{
+	"secrets": map[string]any{"api-key": "this-is-a-secret", "db": `{"password":"p"}`},
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client, err := plugin.Start(ctx, os.Args[0], testConfig)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Requests are multiplexed over a single process
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			secret, err := client.GetSecret(ctx, provider.SecretRef{Key: "api-key"})
+			assert.NoError(t, err)
+			assert.Equal(t, "this-is-a-secret", string(secret))
+		}()
+	}
+	wg.Wait()
+
+	_, err = client.GetSecret(ctx, provider.SecretRef{Key: "missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "getSecret: secret missing not found (code -32603)")
+
+	keys, err := client.ListSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api-key", "db"}, keys)
+
+	require.NoError(t, client.Close())
+}
+
+func TestClient_InitializeError(t *testing.T) {
+	t.Parallel()
+
+	_, err := plugin.Start(context.Background(), os.Args[0], nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to initialize plugin")
+	assert.Contains(t, err.Error(), "secrets is required")
+}
+
+func TestClient_PluginExits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client, err := plugin.Start(ctx, os.Args[0], testConfig)
+	require.NoError(t, err)
+
+	_, err = client.GetSecret(ctx, provider.SecretRef{Key: "exit"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "plugin exited")
+
+	err = client.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 3")
+}
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		request  string
+		expected string
+	}{
+		"unsupported protocol version": {
+			request:  `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":99}}`,
+			expected: `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"unsupported protocol version 99"}}`,
+		},
+		"unknown method": {
+			request:  `{"jsonrpc":"2.0","id":1,"method":"deleteSecret"}`,
+			expected: `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found: deleteSecret"}}`,
+		},
+		"shutdown": {
+			request:  `{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+			expected: `{"jsonrpc":"2.0","id":1,"result":{}}`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r, w := io.Pipe()
+			out := &syncBuffer{}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- plugin.Serve(context.Background(), r, out, &testHandler{})
+			}()
+
+			_, err := io.WriteString(w, tt.request+"\n")
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			require.NoError(t, <-errCh)
+			assert.Equal(t, tt.expected+"\n", out.String())
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(plugin.DirEnv, dir)
+	t.Setenv("PATH", "")
+
+	name := plugin.BinaryPrefix + "broker"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+
+	found, err := plugin.Find("broker")
+	require.NoError(t, err)
+	assert.Equal(t, path, found)
+
+	_, err = plugin.Find("missing")
+	require.EqualError(t, err, "plugin genv-provider-missing not found in the plugins directory or PATH")
+
+	_, err = plugin.Find("../broker")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid plugin name")
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return string(b.buf)
+}
@@ -0,0 +1,94 @@
+// Package plugin implements secret providers that run out of process, so
+// that providers can be added to genv without changing it.
+//
+// A plugin is an executable named genv-provider-<name>. genv starts it and
+// talks to it with JSON-RPC 2.0 over its standard input and output: each
+// request and response is a single JSON object followed by a newline. The
+// standard error of the plugin is passed through to the standard error of
+// genv, so plugins can write logs there.
+//
+// The protocol has the following methods:
+//
+//	initialize   {"protocolVersion": 1, "config": {...}}  -> {"protocolVersion": 1}
+//	getSecret    {"key": "..."}                           -> {"value": "..."}
+//	listSecrets  {}                                       -> {"keys": ["...", ...]}
+//	shutdown     {}                                       -> {}
+//
+// initialize is the first request. config is the config of the provider in
+// .genv.yaml as it is, and the plugin responds with the version of the
+// protocol it speaks, which must be ProtocolVersion. getSecret may be sent
+// concurrently. After responding to shutdown, or when its standard input is
+// closed, the plugin must exit.
+//
+// Failures are reported with a JSON-RPC error response. Plugins written in Go
+// can use Serve to implement the protocol.
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the version of the protocol spoken by this package.
+const ProtocolVersion = 1
+
+const (
+	MethodInitialize  = "initialize"
+	MethodGetSecret   = "getSecret"
+	MethodListSecrets = "listSecrets"
+	MethodShutdown    = "shutdown"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+type InitializeParams struct {
+	ProtocolVersion int            `json:"protocolVersion"`
+	Config          map[string]any `json:"config,omitempty"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion int `json:"protocolVersion"`
+}
+
+type GetSecretParams struct {
+	Key string `json:"key"`
+}
+
+type GetSecretResult struct {
+	Value string `json:"value"`
+}
+
+type ListSecretsResult struct {
+	Keys []string `json:"keys"`
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error returned by a plugin.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
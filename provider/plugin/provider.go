@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"github.com/mrtc0/genv/provider"
+)
+
+var _ provider.Provider = &Provider{}
+
+// BinaryPrefix is the prefix of the names of plugin executables.
+const BinaryPrefix = "genv-provider-"
+
+// DirEnv is the environment variable that overrides the plugins directory.
+const DirEnv = "GENV_PLUGINS_DIR"
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// PluginProviderConfig holds configuration for the plugin provider.
+type PluginProviderConfig struct {
+	ID string
+	// Name is the name of the plugin, which is looked up with Find.
+	Name string
+	// Path is the path of the plugin executable. If set, Name is not
+	// looked up.
+	Path string
+	// Config is sent to the plugin when it is initialized.
+	Config map[string]any
+}
+
+// Provider satisfies the provider.Provider interface.
+type Provider struct {
+	Config *PluginProviderConfig
+}
+
+func NewProvider(cfg *PluginProviderConfig) provider.Provider {
+	return &Provider{Config: cfg}
+}
+
+// NewClient starts the plugin. The returned client must be closed to stop it.
+func (p *Provider) NewClient(ctx context.Context) (provider.SecretClient, error) {
+	path := p.Config.Path
+	if path == "" {
+		var err error
+		if path, err = Find(p.Config.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return Start(ctx, path, p.Config.Config)
+}
+
+// ValidateName reports whether the name can be used as the name of a plugin.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid plugin name %q: must consist of lowercase letters, digits, \"-\" and \"_\"", name)
+	}
+
+	return nil
+}
+
+// Dir returns the directory plugins are looked up in before PATH: the value
+// of GENV_PLUGINS_DIR if it is set, or genv/plugins in the user config
+// directory (e.g. ~/.config/genv/plugins on Linux).
+func Dir() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "genv", "plugins"), nil
+}
+
+// Find returns the path of the genv-provider-<name> executable, looking it
+// up in Dir and then in PATH.
+func Find(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	binary := BinaryPrefix + name
+
+	if dir, err := Dir(); err == nil {
+		// LookPath also tries the extensions in PATHEXT on Windows.
+		if path, err := exec.LookPath(filepath.Join(dir, binary)); err == nil {
+			return path, nil
+		}
+	}
+
+	path, err := exec.LookPath(binary)
+	if errors.Is(err, exec.ErrDot) {
+		// Do not run a plugin in the current directory because of a
+		// relative PATH entry.
+		return "", fmt.Errorf("plugin %s not found: %w", binary, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("plugin %s not found in the plugins directory or PATH", binary)
+	}
+
+	return path, nil
+}
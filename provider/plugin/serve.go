@@ -0,0 +1,146 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Handler implements a plugin. Its methods may be called concurrently.
+type Handler interface {
+	// Initialize is called with the config of the provider in .genv.yaml
+	// before any other method.
+	Initialize(ctx context.Context, config map[string]any) error
+	GetSecret(ctx context.Context, key string) (string, error)
+	ListSecrets(ctx context.Context) ([]string, error)
+}
+
+// Serve implements the plugin protocol with the handler, reading requests
+// from r and writing responses to w, which are usually the standard input
+// and output of the plugin. It returns nil after shutdown is requested or r
+// is closed.
+func Serve(ctx context.Context, r io.Reader, w io.Writer, h Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &server{w: w, handler: h}
+	defer s.wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			s.respond(response{Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			continue
+		}
+
+		switch req.Method {
+		case MethodShutdown:
+			s.wg.Wait()
+			return s.respond(response{ID: req.ID, Result: json.RawMessage("{}")})
+		case MethodInitialize:
+			// The other requests are not handled until initialize is done.
+			s.handle(ctx, req)
+		default:
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.handle(ctx, req)
+			}()
+		}
+	}
+
+	return scanner.Err()
+}
+
+type server struct {
+	handler Handler
+	wg      sync.WaitGroup
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *server) handle(ctx context.Context, req request) {
+	result, err := s.dispatch(ctx, req)
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		s.respond(response{ID: req.ID, Error: rpcErr})
+		return
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		s.respond(response{ID: req.ID, Error: &Error{Code: CodeInternalError, Message: err.Error()}})
+		return
+	}
+	s.respond(response{ID: req.ID, Result: b})
+}
+
+func (s *server) dispatch(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case MethodInitialize:
+		var params InitializeParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.ProtocolVersion != ProtocolVersion {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("unsupported protocol version %d", params.ProtocolVersion)}
+		}
+		if err := s.handler.Initialize(ctx, params.Config); err != nil {
+			return nil, err
+		}
+		return InitializeResult{ProtocolVersion: ProtocolVersion}, nil
+	case MethodGetSecret:
+		var params GetSecretParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		value, err := s.handler.GetSecret(ctx, params.Key)
+		if err != nil {
+			return nil, err
+		}
+		return GetSecretResult{Value: value}, nil
+	case MethodListSecrets:
+		keys, err := s.handler.ListSecrets(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ListSecretsResult{Keys: keys}, nil
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *server) respond(res response) error {
+	res.JSONRPC = "2.0"
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
@@ -2,11 +2,16 @@ package secretutil
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/tidwall/gjson"
 )
 
+// GetValueFromJSON returns the value at the gjson path property. A leading
+// dot is allowed, as in jq (".password").
 func GetValueFromJSON(secret []byte, property string) ([]byte, error) {
+	property = strings.TrimPrefix(property, ".")
+
 	result := gjson.Get(string(secret), property)
 	if !result.Exists() {
 		return nil, errors.New("property not found in secret")
@@ -14,3 +19,20 @@ func GetValueFromJSON(secret []byte, property string) ([]byte, error) {
 
 	return []byte(result.String()), nil
 }
+
+// GetFieldsFromJSON returns the top-level fields of a JSON object.
+// Values that are not strings are returned as their JSON representation.
+func GetFieldsFromJSON(secret []byte) (map[string]string, error) {
+	result := gjson.ParseBytes(secret)
+	if !result.IsObject() {
+		return nil, errors.New("secret is not a JSON object")
+	}
+
+	fields := make(map[string]string)
+	result.ForEach(func(key, value gjson.Result) bool {
+		fields[key.String()] = value.String()
+		return true
+	})
+
+	return fields, nil
+}
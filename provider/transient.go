@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// throttlingErrorCodes are the error codes the AWS APIs return when requests
+// are throttled.
+var throttlingErrorCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"TooManyRequestsException":               true,
+	"RequestLimitExceeded":                   true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"ProvisionedThroughputExceededException": true,
+}
+
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// Transient marks the error as temporary, so that the request is retried.
+// Clients use it for the errors IsTransient cannot recognize by themselves,
+// such as the errors of their SDKs.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &transientError{err: err}
+}
+
+// IsTransient reports whether the request that failed with the error may
+// succeed if retried: the errors marked with Transient, network timeouts,
+// connections reset or refused, throttling and server errors.
+func IsTransient(err error) bool {
+	var transient *transientError
+	if errors.As(err, &transient) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// The errors of the AWS SDK
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) && throttlingErrorCodes[apiErr.ErrorCode()] {
+		return true
+	}
+	var httpErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &httpErr) && IsTransientStatusCode(httpErr.HTTPStatusCode()) {
+		return true
+	}
+
+	return false
+}
+
+// IsTransientStatusCode reports whether the HTTP request that failed with the
+// status code may succeed if retried.
+func IsTransientStatusCode(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,54 @@
+package provider_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+type apiError struct {
+	code       string
+	statusCode int
+}
+
+func (e *apiError) Error() string       { return e.code }
+func (e *apiError) ErrorCode() string   { return e.code }
+func (e *apiError) HTTPStatusCode() int { return e.statusCode }
+
+func TestIsTransient(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"marked as transient":  {err: fmt.Errorf("get secret: %w", provider.Transient(errors.New("unavailable"))), expected: true},
+		"connection refused":   {err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, expected: true},
+		"connection reset":     {err: fmt.Errorf("read: %w", syscall.ECONNRESET), expected: true},
+		"network timeout":      {err: &net.OpError{Op: "read", Err: &net.DNSError{IsTimeout: true}}, expected: true},
+		"host not found":       {err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, expected: false},
+		"invalid address":      {err: &net.OpError{Op: "dial", Err: &net.AddrError{Err: "missing port in address"}}, expected: false},
+		"unexpected EOF":       {err: io.ErrUnexpectedEOF, expected: true},
+		"throttled":            {err: &apiError{code: "ThrottlingException", statusCode: 400}, expected: true},
+		"too many requests":    {err: &apiError{code: "TooManyRequests", statusCode: 429}, expected: true},
+		"server error":         {err: &apiError{code: "InternalServiceError", statusCode: 500}, expected: true},
+		"not found":            {err: &apiError{code: "ResourceNotFoundException", statusCode: 400}, expected: false},
+		"access denied":        {err: &apiError{code: "AccessDeniedException", statusCode: 403}, expected: false},
+		"other errors":         {err: errors.New("secret not found"), expected: false},
+		"nil is not transient": {err: provider.Transient(nil), expected: false},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, provider.IsTransient(tt.err))
+		})
+	}
+}
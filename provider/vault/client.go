@@ -0,0 +1,183 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/secretutil"
+)
+
+var _ provider.SecretClient = &Client{}
+
+// Client reads secrets from a KV v1 or v2 secrets engine.
+type Client struct {
+	api       *apiClient
+	mount     string
+	kvVersion int
+}
+
+// GetSecret reads the secret at ref.Key and returns its data as a JSON object.
+// If ref.Property is set, it is applied as a gjson path on the data.
+func (c *Client) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+	secret, err := c.read(ctx, ref.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.Property == "" {
+		return secret, nil
+	}
+
+	val, err := secretutil.GetValueFromJSON(secret, ref.Property)
+	if err != nil {
+		return nil, fmt.Errorf("vault: property %q not found in %s: %w", ref.Property, ref.Key, err)
+	}
+
+	return val, nil
+}
+
+func (c *Client) read(ctx context.Context, key string) ([]byte, error) {
+	key = escapePath(strings.Trim(key, "/"))
+
+	path := c.mount + "/" + key
+	if c.kvVersion == 2 {
+		path = c.mount + "/data/" + key
+	}
+
+	data, err := c.api.read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.kvVersion == 1 {
+		return data, nil
+	}
+
+	// KV v2 wraps the secret in {"data": {...}, "metadata": {...}}.
+	// data is null when the latest version has been deleted.
+	var v2 struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &v2); err != nil {
+		return nil, fmt.Errorf("vault: failed to decode response for %s: %w", path, err)
+	}
+	if len(v2.Data) == 0 || string(v2.Data) == "null" {
+		return nil, fmt.Errorf("vault: secret %s has no data (deleted or destroyed)", path)
+	}
+
+	return v2.Data, nil
+}
+
+// apiClient is a minimal client for the Vault HTTP API.
+type apiClient struct {
+	httpClient *http.Client
+	address    string
+	namespace  string
+	token      string
+}
+
+type apiResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Auth   *apiAuth        `json:"auth"`
+	Errors []string        `json:"errors"`
+}
+
+type apiAuth struct {
+	ClientToken string `json:"client_token"`
+}
+
+// escapePath escapes each segment of the path, so that characters such as
+// "?" and "#" stay in the path of the request.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func (a *apiClient) read(ctx context.Context, path string) ([]byte, error) {
+	resp, err := a.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil, fmt.Errorf("vault: GET %s: response has no data", path)
+	}
+
+	return resp.Data, nil
+}
+
+func (a *apiClient) login(ctx context.Context, path string, body any) (string, error) {
+	resp, err := a.do(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault: POST %s: response has no client token", path)
+	}
+
+	return resp.Auth.ClientToken, nil
+}
+
+func (a *apiClient) do(ctx context.Context, method, path string, body any) (*apiResponse, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.address+"/v1/"+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.token != "" {
+		req.Header.Set("X-Vault-Token", a.token)
+	}
+	if a.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", a.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %s %s: %w", method, path, err)
+	}
+	defer res.Body.Close()
+
+	var resp apiResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("vault: %s %s: failed to decode response: %w", method, path, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		msg := res.Status
+		if len(resp.Errors) > 0 {
+			msg += ": " + strings.Join(resp.Errors, "; ")
+		}
+		err := fmt.Errorf("vault: %s %s: %s", method, path, msg)
+		if provider.IsTransientStatusCode(res.StatusCode) {
+			return nil, provider.Transient(err)
+		}
+		return nil, err
+	}
+
+	return &resp, nil
+}
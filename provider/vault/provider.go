@@ -0,0 +1,186 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrtc0/genv/provider"
+)
+
+const (
+	VaultAddrEnv      = "VAULT_ADDR"
+	VaultTokenEnv     = "VAULT_TOKEN"
+	VaultNamespaceEnv = "VAULT_NAMESPACE"
+	VaultRoleIDEnv    = "VAULT_ROLE_ID"
+	VaultSecretIDEnv  = "VAULT_SECRET_ID"
+
+	defaultMount        = "secret"
+	defaultAppRoleMount = "approle"
+	defaultKVVersion    = 2
+)
+
+type AuthMethod string
+
+const (
+	// AuthMethodToken reads the token from the VAULT_TOKEN environment variable.
+	AuthMethodToken AuthMethod = "token"
+	// AuthMethodTokenFile reads the token from a file, ~/.vault-token by default.
+	AuthMethodTokenFile AuthMethod = "token-file"
+	// AuthMethodAppRole logs in with a role ID and secret ID.
+	AuthMethodAppRole AuthMethod = "approle"
+)
+
+var _ provider.Provider = &Provider{}
+
+type VaultProviderConfig struct {
+	ID        string
+	Address   string
+	Namespace string
+	// Mount is the path the KV secrets engine is mounted at. Defaults to "secret".
+	Mount string
+	// KVVersion is the version of the KV secrets engine, 1 or 2. Defaults to 2.
+	KVVersion int
+	Auth      VaultAuth
+}
+
+type VaultAuth struct {
+	Method AuthMethod
+	// TokenFile is the path of the token file (only applicable when Method is token-file).
+	TokenFile string
+	// RoleID, SecretIDFile and Mount are only applicable when Method is approle.
+	// When RoleID or SecretIDFile are omitted, VAULT_ROLE_ID and VAULT_SECRET_ID are used.
+	RoleID       string
+	SecretIDFile string
+	Mount        string
+}
+
+// Provider satisfies the provider.Provider interface.
+type Provider struct {
+	Config     *VaultProviderConfig
+	HTTPClient *http.Client
+}
+
+func NewProvider(cfg *VaultProviderConfig) provider.Provider {
+	return &Provider{Config: cfg, HTTPClient: http.DefaultClient}
+}
+
+func (p *Provider) NewClient(ctx context.Context) (provider.SecretClient, error) {
+	address := p.Config.Address
+	if address == "" {
+		address = os.Getenv(VaultAddrEnv)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("vault provider %q: address is required (set address or %s)", p.Config.ID, VaultAddrEnv)
+	}
+
+	namespace := p.Config.Namespace
+	if namespace == "" {
+		namespace = os.Getenv(VaultNamespaceEnv)
+	}
+
+	kvVersion := p.Config.KVVersion
+	if kvVersion == 0 {
+		kvVersion = defaultKVVersion
+	}
+	if kvVersion != 1 && kvVersion != 2 {
+		return nil, fmt.Errorf("vault provider %q: unsupported KV version: %d", p.Config.ID, kvVersion)
+	}
+
+	mount := strings.Trim(p.Config.Mount, "/")
+	if mount == "" {
+		mount = defaultMount
+	}
+
+	api := &apiClient{
+		httpClient: p.HTTPClient,
+		address:    strings.TrimSuffix(address, "/"),
+		namespace:  namespace,
+	}
+
+	token, err := p.token(ctx, api)
+	if err != nil {
+		return nil, fmt.Errorf("vault provider %q: %w", p.Config.ID, err)
+	}
+	api.token = token
+
+	return &Client{
+		api:       api,
+		mount:     mount,
+		kvVersion: kvVersion,
+	}, nil
+}
+
+func (p *Provider) token(ctx context.Context, api *apiClient) (string, error) {
+	auth := p.Config.Auth
+
+	switch auth.Method {
+	case AuthMethodToken, "":
+		token := os.Getenv(VaultTokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("%s must be set when using token authentication", VaultTokenEnv)
+		}
+		return token, nil
+	case AuthMethodTokenFile:
+		path := auth.TokenFile
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			path = filepath.Join(home, ".vault-token")
+		}
+		return readTrimmedFile(path)
+	case AuthMethodAppRole:
+		return p.appRoleLogin(ctx, api)
+	default:
+		return "", errors.New("unsupported auth method: " + string(auth.Method))
+	}
+}
+
+func (p *Provider) appRoleLogin(ctx context.Context, api *apiClient) (string, error) {
+	auth := p.Config.Auth
+
+	roleID := auth.RoleID
+	if roleID == "" {
+		roleID = os.Getenv(VaultRoleIDEnv)
+	}
+	if roleID == "" {
+		return "", fmt.Errorf("roleID or %s must be set when using approle authentication", VaultRoleIDEnv)
+	}
+
+	secretID := os.Getenv(VaultSecretIDEnv)
+	if auth.SecretIDFile != "" {
+		s, err := readTrimmedFile(auth.SecretIDFile)
+		if err != nil {
+			return "", err
+		}
+		secretID = s
+	}
+	if secretID == "" {
+		return "", fmt.Errorf("secretIDFile or %s must be set when using approle authentication", VaultSecretIDEnv)
+	}
+
+	mount := strings.Trim(auth.Mount, "/")
+	if mount == "" {
+		mount = defaultAppRoleMount
+	}
+
+	return api.login(ctx, "auth/"+mount+"/login", map[string]string{
+		"role_id":   roleID,
+		"secret_id": secretID,
+	})
+}
+
+func readTrimmedFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
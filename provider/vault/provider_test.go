@@ -0,0 +1,256 @@
+package vault_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	dummyToken    = "s.dummy-token"
+	dummyRoleID   = "dummy-role-id"
+	dummySecretID = "dummy-secret-id"
+)
+
+// newMockVaultServer returns a stand-in for the Vault HTTP API that serves
+// a KV v1 engine at "kv", a KV v2 engine at "secret" and AppRole login at "approle".
+func newMockVaultServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	writeJSON := func(w http.ResponseWriter, status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if body["role_id"] != dummyRoleID || body["secret_id"] != dummySecretID {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"auth": map[string]any{"client_token": dummyToken}})
+	})
+	mux.HandleFunc("GET /v1/kv/myapp/db", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"data": map[string]any{"username": "v1-user", "password": "v1-password"},
+		})
+	})
+	mux.HandleFunc("GET /v1/secret/data/myapp/db", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"data": map[string]any{
+				"data":     map[string]any{"username": "v2-user", "password": "v2-password"},
+				"metadata": map[string]any{"version": 3},
+			},
+		})
+	})
+	mux.HandleFunc("GET /v1/secret/data/myapp/token%3Fv2", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"data": map[string]any{
+				"data":     map[string]any{"token": "v2-token"},
+				"metadata": map[string]any{"version": 1},
+			},
+		})
+	})
+	mux.HandleFunc("GET /v1/secret/data/myapp/deleted", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"data": map[string]any{
+				"data":     nil,
+				"metadata": map[string]any{"version": 1, "deletion_time": "2024-01-01T00:00:00Z"},
+			},
+		})
+	})
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/approle/login" && r.Header.Get("X-Vault-Token") != dummyToken {
+			writeJSON(w, http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}})
+			return
+		}
+
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			writeJSON(w, http.StatusNotFound, map[string]any{"errors": []string{}})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestClient_GetSecret(t *testing.T) {
+	ts := newMockVaultServer(t)
+	defer ts.Close()
+
+	t.Setenv(vault.VaultTokenEnv, dummyToken)
+
+	type want struct {
+		secret []byte
+		errMsg string // empty means no error expected
+	}
+
+	testCases := map[string]struct {
+		config *vault.VaultProviderConfig
+		ref    provider.SecretRef
+		want   want
+	}{
+		"KV v2 with property": {
+			config: &vault.VaultProviderConfig{},
+			ref:    provider.SecretRef{Key: "myapp/db", Property: "password"},
+			want:   want{secret: []byte("v2-password")},
+		},
+		"KV v2 without property": {
+			config: &vault.VaultProviderConfig{KVVersion: 2, Mount: "secret"},
+			ref:    provider.SecretRef{Key: "myapp/db"},
+			want:   want{secret: []byte(`{"password":"v2-password","username":"v2-user"}`)},
+		},
+		"KV v1 with property": {
+			config: &vault.VaultProviderConfig{KVVersion: 1, Mount: "kv"},
+			ref:    provider.SecretRef{Key: "myapp/db", Property: "username"},
+			want:   want{secret: []byte("v1-user")},
+		},
+		"key with reserved characters": {
+			config: &vault.VaultProviderConfig{},
+			ref:    provider.SecretRef{Key: "myapp/token?v2", Property: "token"},
+			want:   want{secret: []byte("v2-token")},
+		},
+		"secret not found": {
+			config: &vault.VaultProviderConfig{},
+			ref:    provider.SecretRef{Key: "myapp/missing"},
+			want:   want{errMsg: "vault: GET secret/data/myapp/missing: 404 Not Found"},
+		},
+		"deleted KV v2 secret": {
+			config: &vault.VaultProviderConfig{},
+			ref:    provider.SecretRef{Key: "myapp/deleted"},
+			want:   want{errMsg: "vault: secret secret/data/myapp/deleted has no data"},
+		},
+		"property not found": {
+			config: &vault.VaultProviderConfig{},
+			ref:    provider.SecretRef{Key: "myapp/db", Property: "nonexistent"},
+			want:   want{errMsg: `vault: property "nonexistent" not found in myapp/db`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tc.config.ID = "vault"
+			tc.config.Address = ts.URL
+
+			client, err := vault.NewProvider(tc.config).NewClient(context.Background())
+			require.NoError(t, err)
+
+			got, err := client.GetSecret(context.Background(), tc.ref)
+			if tc.want.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.want.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want.secret, got)
+		})
+	}
+}
+
+func TestNewClient_Auth(t *testing.T) {
+	ts := newMockVaultServer(t)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(dummyToken+"\n"), 0600))
+	secretIDFile := filepath.Join(dir, "secret-id")
+	require.NoError(t, os.WriteFile(secretIDFile, []byte(dummySecretID), 0600))
+	home := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".vault-token"), []byte(dummyToken+"\n"), 0600))
+
+	testCases := map[string]struct {
+		auth   vault.VaultAuth
+		env    map[string]string
+		errMsg string // empty means no error expected
+	}{
+		"token from environment": {
+			auth: vault.VaultAuth{Method: vault.AuthMethodToken},
+			env:  map[string]string{vault.VaultTokenEnv: dummyToken},
+		},
+		"token not set": {
+			auth:   vault.VaultAuth{Method: vault.AuthMethodToken},
+			errMsg: "VAULT_TOKEN must be set",
+		},
+		"token file": {
+			auth: vault.VaultAuth{Method: vault.AuthMethodTokenFile, TokenFile: tokenFile},
+		},
+		"token file in home directory": {
+			auth: vault.VaultAuth{Method: vault.AuthMethodTokenFile},
+			env:  map[string]string{"HOME": home, "USERPROFILE": home},
+		},
+		"approle with secret ID file": {
+			auth: vault.VaultAuth{Method: vault.AuthMethodAppRole, RoleID: dummyRoleID, SecretIDFile: secretIDFile},
+		},
+		"approle with environment": {
+			auth: vault.VaultAuth{Method: vault.AuthMethodAppRole},
+			env: map[string]string{
+				vault.VaultRoleIDEnv:   dummyRoleID,
+				vault.VaultSecretIDEnv: dummySecretID,
+			},
+		},
+		"approle with invalid secret ID": {
+			auth: vault.VaultAuth{Method: vault.AuthMethodAppRole, RoleID: dummyRoleID},
+			env: map[string]string{
+				vault.VaultSecretIDEnv: "invalid",
+			},
+			errMsg: "vault: POST auth/approle/login: 400 Bad Request: invalid role or secret ID",
+		},
+		"unsupported method": {
+			auth:   vault.VaultAuth{Method: "kubernetes"},
+			errMsg: "unsupported auth method: kubernetes",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(vault.VaultTokenEnv, "")
+			t.Setenv(vault.VaultRoleIDEnv, "")
+			t.Setenv(vault.VaultSecretIDEnv, "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			client, err := vault.NewProvider(&vault.VaultProviderConfig{
+				ID:      "vault",
+				Address: ts.URL,
+				Auth:    tc.auth,
+			}).NewClient(context.Background())
+			if tc.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+
+			got, err := client.GetSecret(context.Background(), provider.SecretRef{Key: "myapp/db", Property: "username"})
+			require.NoError(t, err)
+			assert.Equal(t, []byte("v2-user"), got)
+		})
+	}
+}
+
+func TestNewClient_Address(t *testing.T) {
+	t.Setenv(vault.VaultAddrEnv, "")
+	t.Setenv(vault.VaultTokenEnv, dummyToken)
+
+	_, err := vault.NewProvider(&vault.VaultProviderConfig{ID: "vault"}).NewClient(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "address is required")
+}
@@ -0,0 +1,63 @@
+package genv
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/aws"
+)
+
+func init() {
+	mustRegisterProviderType(ProviderType{
+		Name:        "aws",
+		DisplayName: "AWS",
+		ConfigType:  reflect.TypeFor[AwsProvider](),
+		Decode:      DecodeProviderConfig[AwsProvider],
+		New:         newAwsProvider,
+	})
+}
+
+type AwsProvider struct {
+	ID string `yaml:"id" jsonschema:"required"`
+	// The AWS service to retrieve secrets from
+	// Possible values are "SecretsManager" and "ParameterStore"
+	Service aws.AwsSecretService `yaml:"service"`
+	Region  string               `yaml:"region,omitempty"`
+	Auth    AwsAuth              `yaml:"auth,omitempty"`
+
+	ProviderSettings `yaml:",inline"`
+}
+
+type AwsAuth struct {
+	Profile                string   `yaml:"profile,omitempty"`
+	SharedCredentialsFiles []string `yaml:"sharedCredentialsFiles,omitempty"`
+	SharedConfigFiles      []string `yaml:"sharedConfigFiles,omitempty"`
+}
+
+func (p AwsProvider) ProviderID() string { return p.ID }
+
+func (p AwsProvider) Validate() error {
+	switch p.Service {
+	case "", aws.AWSSecretsManager, aws.SSMParameterStore:
+		return nil
+	default:
+		return fmt.Errorf("unsupported service %q", p.Service)
+	}
+}
+
+func newAwsProvider(config ProviderConfig) (provider.Provider, error) {
+	p := config.(AwsProvider)
+
+	return aws.NewProvider(&aws.AwsProviderConfig{
+		ID:      p.ID,
+		Service: p.Service,
+		Region:  p.Region,
+		Auth: aws.AwsAuth{
+			Profile:                p.Auth.Profile,
+			SharedCredentialsFiles: p.Auth.SharedCredentialsFiles,
+			SharedConfigFiles:      p.Auth.SharedConfigFiles,
+		},
+		DisableRetries: p.retries() > 0,
+	}), nil
+}
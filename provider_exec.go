@@ -0,0 +1,77 @@
+package genv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/exec"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	mustRegisterProviderType(ProviderType{
+		Name:       "exec",
+		ConfigType: reflect.TypeFor[ExecProvider](),
+		Decode:     DecodeProviderConfig[ExecProvider],
+		New:        newExecProvider,
+	})
+}
+
+// ExecCommand supports two YAML forms for specifying a command:
+//
+//	String form:   command: "vault kv get -format=json secret/myapp | jq .data"
+//	Sequence form: command: ["vault", "kv", "get", "-format=json", "secret/myapp"]
+//
+// The string form is passed to "sh -c" so that shell features such as pipes
+// and redirections work. The sequence form is executed directly via execve,
+// which avoids shell interpretation and is safer when the arguments are known
+// at configuration time.
+type ExecCommand struct {
+	Args []string
+}
+
+// UnmarshalYAML allows ExecCommand to accept either a plain string or a
+// sequence of strings in YAML.
+//
+//   - Scalar (string): wrapped as ["sh", "-c", <value>] so the shell handles
+//     pipes, redirections, etc.
+//   - Sequence: decoded as-is and executed directly without a shell.
+func (c *ExecCommand) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		c.Args = []string{"sh", "-c", value.Value}
+	case yaml.SequenceNode:
+		return value.Decode(&c.Args)
+	default:
+		return fmt.Errorf("command must be a string or sequence")
+	}
+	return nil
+}
+
+type ExecProvider struct {
+	ID      string      `yaml:"id" jsonschema:"required"`
+	Command ExecCommand `yaml:"command"`
+
+	ProviderSettings `yaml:",inline"`
+}
+
+func (p ExecProvider) ProviderID() string { return p.ID }
+
+func (p ExecProvider) Validate() error {
+	if len(p.Command.Args) == 0 {
+		return errors.New("command is required")
+	}
+
+	return nil
+}
+
+func newExecProvider(config ProviderConfig) (provider.Provider, error) {
+	p := config.(ExecProvider)
+
+	return exec.NewProvider(&exec.ExecProviderConfig{
+		ID:      p.ID,
+		Command: p.Command.Args,
+	}), nil
+}
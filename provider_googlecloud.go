@@ -0,0 +1,55 @@
+package genv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/googlecloud"
+)
+
+func init() {
+	mustRegisterProviderType(ProviderType{
+		Name:        "googleCloud",
+		DisplayName: "Google Cloud",
+		ConfigType:  reflect.TypeFor[GoogleCloudProvider](),
+		Decode:      DecodeProviderConfig[GoogleCloudProvider],
+		New:         newGoogleCloudProvider,
+	})
+}
+
+type GoogleCloudProvider struct {
+	ID        string `yaml:"id" jsonschema:"required"`
+	Service   string `yaml:"service"`
+	ProjectID string `yaml:"projectID"`
+	Location  string `yaml:"location,omitempty"`
+
+	ProviderSettings `yaml:",inline"`
+}
+
+func (p GoogleCloudProvider) ProviderID() string { return p.ID }
+
+func (p GoogleCloudProvider) Validate() error {
+	var errs []error
+	if p.Service != "SecretManager" {
+		errs = append(errs, fmt.Errorf("unsupported service %q", p.Service))
+	}
+	if p.ProjectID == "" {
+		errs = append(errs, errors.New("projectID is required"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func newGoogleCloudProvider(config ProviderConfig) (provider.Provider, error) {
+	p := config.(GoogleCloudProvider)
+
+	return googlecloud.NewProvider(&googlecloud.GoogleCloudProvider{
+		ID:             p.ID,
+		Service:        p.Service,
+		ProjectID:      p.ProjectID,
+		Location:       p.Location,
+		DisableRetries: p.retries() > 0,
+	}), nil
+}
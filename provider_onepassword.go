@@ -0,0 +1,56 @@
+package genv
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/onepassword"
+)
+
+func init() {
+	mustRegisterProviderType(ProviderType{
+		Name:        "1password",
+		DisplayName: "1Password",
+		ConfigType:  reflect.TypeFor[OnePasswordProvider](),
+		Decode:      DecodeProviderConfig[OnePasswordProvider],
+		New:         newOnePasswordProvider,
+	})
+}
+
+type OnePasswordProvider struct {
+	ID   string          `yaml:"id" jsonschema:"required"`
+	Auth OnePasswordAuth `yaml:"auth,omitempty"`
+
+	ProviderSettings `yaml:",inline"`
+}
+
+// OnePasswordAuth represents the authentication configuration for 1Password
+type OnePasswordAuth struct {
+	// The authentication method to use for 1Password
+	// Possible values are "cli" and "service-account"
+	// If omitted, defaults to "cli"
+	Method onepassword.OnePasswordAuthMethod `yaml:"method"`
+	// The account to use for 1Password (only applicable when Method is CLI)
+	Account string `yaml:"account,omitempty"`
+}
+
+func (p OnePasswordProvider) ProviderID() string { return p.ID }
+
+func (p OnePasswordProvider) Validate() error {
+	switch p.Auth.Method {
+	case "", onepassword.OnePasswordAuthMethodCLI, onepassword.OnePasswordAuthMethodServiceAccount:
+		return nil
+	default:
+		return fmt.Errorf("unsupported auth method %q", p.Auth.Method)
+	}
+}
+
+func newOnePasswordProvider(config ProviderConfig) (provider.Provider, error) {
+	p := config.(OnePasswordProvider)
+
+	return onepassword.NewProvider(
+		onepassword.WithAccount(p.Auth.Account),
+		onepassword.WithAuthMethod(p.Auth.Method),
+	), nil
+}
@@ -0,0 +1,92 @@
+package genv
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	mustRegisterProviderType(ProviderType{
+		Name:       "plugin",
+		ConfigType: reflect.TypeFor[PluginProvider](),
+		Decode:     DecodeProviderConfig[PluginProvider],
+		New:        newPluginProvider,
+	})
+}
+
+// PluginProvider runs an out-of-process provider plugin, an executable named
+// genv-provider-<name>. See the provider/plugin package for the protocol.
+type PluginProvider struct {
+	ID string `yaml:"id" jsonschema:"required"`
+	// The name of the plugin, which is looked up in the plugins directory
+	// and then in PATH
+	Name string `yaml:"name,omitempty"`
+	// The path of the plugin executable, instead of looking up Name. A
+	// relative path is relative to the directory of the config file that
+	// sets it.
+	Path string `yaml:"path,omitempty"`
+	// The config passed to the plugin as it is
+	Config map[string]any `yaml:"config,omitempty"`
+
+	ProviderSettings `yaml:",inline"`
+}
+
+func (p PluginProvider) ProviderID() string { return p.ID }
+
+func (p PluginProvider) Validate() error {
+	switch {
+	case p.Name == "" && p.Path == "":
+		return errors.New("name or path is required")
+	case p.Name != "" && p.Path != "":
+		return errors.New("only one of name and path can be set")
+	case p.Name != "":
+		return plugin.ValidateName(p.Name)
+	}
+
+	return nil
+}
+
+func newPluginProvider(config ProviderConfig) (provider.Provider, error) {
+	p := config.(PluginProvider)
+
+	return plugin.NewProvider(&plugin.PluginProviderConfig{
+		ID:     p.ID,
+		Name:   p.Name,
+		Path:   p.Path,
+		Config: p.Config,
+	}), nil
+}
+
+// resolvePluginPaths makes the relative paths of the plugins absolute, based
+// on the directory of the config file that sets each of them rather than the
+// working directory.
+func (l *configLoader) resolvePluginPaths(root *yaml.Node) error {
+	items := mappingValue(mappingValue(root, "secretProvider"), "plugin")
+	if items == nil || items.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	for _, item := range items.Content {
+		path := mappingValue(item, "path")
+		if path == nil || path.Kind != yaml.ScalarNode || path.Value == "" || filepath.IsAbs(path.Value) {
+			continue
+		}
+
+		file := l.files[path]
+		if file == "" {
+			continue
+		}
+		dir, err := filepath.Abs(filepath.Dir(file))
+		if err != nil {
+			return err
+		}
+		path.Value = filepath.Join(dir, path.Value)
+	}
+
+	return nil
+}
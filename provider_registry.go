@@ -0,0 +1,179 @@
+package genv
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/mrtc0/genv/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// ProviderType is a type of secret provider, configured as a list under its
+// name in the secretProvider section of the config file:
+//
+//	secretProvider:
+//	  <name>:
+//	    - id: my-provider
+//	      ...
+//
+// Programs that embed genv can add provider types with RegisterProviderType.
+type ProviderType struct {
+	// Name is the key of the type in the secretProvider section.
+	Name string
+	// DisplayName is the name of the type used in messages.
+	// If omitted, Name is used.
+	DisplayName string
+	// ConfigType is the type of the configurations Decode returns. If set,
+	// LoadConfig reports the unknown fields of the configurations, and
+	// JSONSchema describes them.
+	ConfigType reflect.Type
+	// Decode decodes the configuration of a provider from its YAML node. If
+	// ConfigType is not set, it is responsible for rejecting unknown fields.
+	// DecodeProviderConfig decodes into the ConfigType.
+	Decode func(node *yaml.Node) (ProviderConfig, error)
+	// New creates the provider from the configuration.
+	New func(config ProviderConfig) (provider.Provider, error)
+}
+
+// ProviderConfig is the configuration of a provider.
+type ProviderConfig interface {
+	// ProviderID returns the ID the envs refer to the provider by.
+	ProviderID() string
+	// Settings returns the settings shared by all secret providers.
+	Settings() ProviderSettings
+}
+
+// ProviderConfigValidator is implemented by the ProviderConfigs that can
+// check themselves. It is used by Config.Validate, which reports each of the
+// joined errors as a separate problem.
+type ProviderConfigValidator interface {
+	Validate() error
+}
+
+var (
+	providerTypesMu sync.RWMutex
+	providerTypes   = make(map[string]ProviderType)
+)
+
+// RegisterProviderType adds a provider type. It must be called before the
+// config is loaded, typically from an init function.
+func RegisterProviderType(t ProviderType) error {
+	switch {
+	case t.Name == "":
+		return errors.New("provider type name is required")
+	case t.Decode == nil:
+		return fmt.Errorf("provider type %s: Decode is required", t.Name)
+	case t.New == nil:
+		return fmt.Errorf("provider type %s: New is required", t.Name)
+	}
+
+	providerTypesMu.Lock()
+	defer providerTypesMu.Unlock()
+
+	if _, ok := providerTypes[t.Name]; ok {
+		return fmt.Errorf("provider type %s is already registered", t.Name)
+	}
+	providerTypes[t.Name] = t
+
+	return nil
+}
+
+func lookupProviderType(name string) (ProviderType, bool) {
+	providerTypesMu.RLock()
+	defer providerTypesMu.RUnlock()
+
+	t, ok := providerTypes[name]
+	return t, ok
+}
+
+func (t ProviderType) displayName() string {
+	if t.DisplayName != "" {
+		return t.DisplayName
+	}
+
+	return t.Name
+}
+
+// providerEntry is a provider defined in the config.
+type providerEntry struct {
+	// kind is the name of the ProviderType.
+	kind string
+	// index is the index of the provider in the list of its type.
+	index  int
+	config ProviderConfig
+}
+
+// providers returns all providers in the config, by the name of their type.
+func (sp SecretProvider) providers() []providerEntry {
+	var entries []providerEntry
+	for _, kind := range slices.Sorted(maps.Keys(sp.configs)) {
+		for i, c := range sp.configs[kind] {
+			entries = append(entries, providerEntry{kind: kind, index: i, config: c})
+		}
+	}
+
+	return entries
+}
+
+// decodeProviders decodes the providers with the decoders of their types.
+// The keys that are not provider types are reported by LoadConfig.
+func (sp *SecretProvider) decodeProviders(node *yaml.Node) error {
+	sp.configs = nil
+
+	var errs []error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		kind, items := node.Content[i].Value, node.Content[i+1]
+
+		t, ok := lookupProviderType(kind)
+		if !ok || t.Decode == nil || isNull(items) {
+			continue
+		}
+		if items.Kind != yaml.SequenceNode {
+			errs = append(errs, &nodeError{node: items, err: fmt.Errorf("secretProvider.%s must be a list", kind)})
+			continue
+		}
+
+		for _, item := range items.Content {
+			config, err := t.Decode(item)
+			if err != nil {
+				errs = append(errs, &nodeError{node: item, err: fmt.Errorf("%s provider: %w", kind, err)})
+				continue
+			}
+
+			sp.add(kind, config)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (sp *SecretProvider) add(kind string, configs ...ProviderConfig) {
+	if sp.configs == nil {
+		sp.configs = make(map[string][]ProviderConfig)
+	}
+	sp.configs[kind] = append(sp.configs[kind], configs...)
+}
+
+// DecodeProviderConfig decodes the configuration of a provider into a T. It
+// can be used as the Decode of the provider types whose ConfigType is T.
+func DecodeProviderConfig[T ProviderConfig](node *yaml.Node) (ProviderConfig, error) {
+	var c T
+	if err := node.Decode(&c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s ProviderSettings) Settings() ProviderSettings { return s }
+
+// mustRegisterProviderType registers a built-in provider type.
+func mustRegisterProviderType(t ProviderType) {
+	if err := RegisterProviderType(t); err != nil {
+		panic(err)
+	}
+}
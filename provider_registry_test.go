@@ -0,0 +1,195 @@
+package genv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mrtc0/genv"
+	"github.com/mrtc0/genv/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// staticProviderConfig is the configuration of the provider type registered
+// for the tests, whose secrets are listed in the config. It is the only type
+// the tests register.
+type staticProviderConfig struct {
+	ID                    string            `yaml:"id"`
+	Secrets               map[string]string `yaml:"secrets"`
+	genv.ProviderSettings `yaml:",inline"`
+
+	// created, if set, counts the providers created from the config. It
+	// can only be set when the config is built without loading a file.
+	created *atomic.Int32
+}
+
+func (c staticProviderConfig) ProviderID() string { return c.ID }
+
+func (c staticProviderConfig) Validate() error {
+	if len(c.Secrets) == 0 {
+		return errors.New("secrets is required")
+	}
+
+	return nil
+}
+
+type staticProvider struct {
+	secrets map[string]string
+}
+
+func (p *staticProvider) NewClient(ctx context.Context) (provider.SecretClient, error) {
+	return p, nil
+}
+
+func (p *staticProvider) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+	v, ok := p.secrets[ref.Key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	return []byte(v), nil
+}
+
+func init() {
+	err := genv.RegisterProviderType(genv.ProviderType{
+		Name: "static",
+		Decode: func(node *yaml.Node) (genv.ProviderConfig, error) {
+			var c staticProviderConfig
+			if err := node.Decode(&c); err != nil {
+				return nil, err
+			}
+			return c, nil
+		},
+		New: func(config genv.ProviderConfig) (provider.Provider, error) {
+			c := config.(staticProviderConfig)
+			if c.created != nil {
+				c.created.Add(1)
+			}
+
+			return &staticProvider{secrets: c.Secrets}, nil
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterProviderType(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, t.TempDir(), ".genv.yaml", `secretProvider:
+  static:
+    - id: fixture
+      concurrency: 1
+      secrets:
+        api-key: this-is-a-secret
+envs:
+  API_KEY:
+    secretRef:
+      provider: fixture
+      key: api-key
+`)
+
+	cfg, err := genv.LoadConfig(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	svc, err := genv.NewSecretProviderService(context.Background(), cfg.SecretProvider)
+	require.NoError(t, err)
+
+	secret, err := svc.GetSecret(context.Background(), "fixture", genv.GetSecretInput{Key: "api-key"})
+	require.NoError(t, err)
+	assert.Equal(t, "this-is-a-secret", string(secret))
+}
+
+func TestRegisterProviderType_Validate(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, t.TempDir(), ".genv.yaml", `secretProvider:
+  exec:
+    - id: shared
+      command: ["tool"]
+  static:
+    - id: shared
+`)
+
+	cfg, err := genv.LoadConfig(path)
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Equal(t, path+`:6:7: duplicate provider id "shared", already used by the exec provider (defined in `+path+`:3:7)
+`+path+`:6:7: static provider "shared": secrets is required`, err.Error())
+}
+
+func TestRegisterProviderType_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		providerType genv.ProviderType
+		errMsg       string
+	}{
+		"without name": {
+			providerType: genv.ProviderType{},
+			errMsg:       "provider type name is required",
+		},
+		"without decoder": {
+			providerType: genv.ProviderType{Name: "nodecoder"},
+			errMsg:       "provider type nodecoder: Decode is required",
+		},
+		"built-in type": {
+			providerType: genv.ProviderType{
+				Name:   "aws",
+				Decode: func(node *yaml.Node) (genv.ProviderConfig, error) { return nil, nil },
+				New:    func(config genv.ProviderConfig) (provider.Provider, error) { return nil, nil },
+			},
+			errMsg: "provider type aws is already registered",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.EqualError(t, genv.RegisterProviderType(tt.providerType), tt.errMsg)
+		})
+	}
+}
+
+func TestLoadConfig_ProviderErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		config string
+		errMsg string
+	}{
+		"unknown provider type": {
+			config: "secretProvider:\n  awz:\n    - id: aws\n",
+			errMsg: `:2:3: unknown field "awz" in secretProvider`,
+		},
+		"not a list": {
+			config: "secretProvider:\n  static:\n    id: fixture\n",
+			errMsg: `:3:5: secretProvider.static must be a list`,
+		},
+		"decode error": {
+			config: "secretProvider:\n  static:\n    - id: fixture\n      secrets: [api-key]\n",
+			errMsg: `:3:7: static provider: yaml: unmarshal errors:`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeConfigFile(t, t.TempDir(), ".genv.yaml", tt.config)
+
+			_, err := genv.LoadConfig(path)
+			require.Error(t, err)
+			assert.True(t, strings.HasPrefix(err.Error(), path+tt.errMsg), err.Error())
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package genv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/vault"
+)
+
+func init() {
+	mustRegisterProviderType(ProviderType{
+		Name:        "vault",
+		DisplayName: "Vault",
+		ConfigType:  reflect.TypeFor[VaultProvider](),
+		Decode:      DecodeProviderConfig[VaultProvider],
+		New:         newVaultProvider,
+	})
+}
+
+type VaultProvider struct {
+	ID        string `yaml:"id" jsonschema:"required"`
+	Address   string `yaml:"address,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+	// The path the KV secrets engine is mounted at. Defaults to "secret".
+	Mount string `yaml:"mount,omitempty"`
+	// The version of the KV secrets engine, 1 or 2. Defaults to 2.
+	KVVersion int       `yaml:"kvVersion,omitempty"`
+	Auth      VaultAuth `yaml:"auth,omitempty"`
+
+	ProviderSettings `yaml:",inline"`
+}
+
+// VaultAuth represents the authentication configuration for HashiCorp Vault
+type VaultAuth struct {
+	// The authentication method to use for Vault
+	// Possible values are "token", "token-file" and "approle"
+	// If omitted, defaults to "token"
+	Method vault.AuthMethod `yaml:"method,omitempty"`
+	// The path to the token file (only applicable when Method is token-file)
+	TokenFile string `yaml:"tokenFile,omitempty"`
+	// The AppRole settings (only applicable when Method is approle)
+	RoleID       string `yaml:"roleID,omitempty"`
+	SecretIDFile string `yaml:"secretIDFile,omitempty"`
+	Mount        string `yaml:"mount,omitempty"`
+}
+
+func (p VaultProvider) ProviderID() string { return p.ID }
+
+func (p VaultProvider) Validate() error {
+	var errs []error
+	switch p.KVVersion {
+	case 0, 1, 2:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported kvVersion %d", p.KVVersion))
+	}
+	switch p.Auth.Method {
+	case "", vault.AuthMethodToken, vault.AuthMethodTokenFile, vault.AuthMethodAppRole:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported auth method %q", p.Auth.Method))
+	}
+
+	return errors.Join(errs...)
+}
+
+func newVaultProvider(config ProviderConfig) (provider.Provider, error) {
+	p := config.(VaultProvider)
+
+	return vault.NewProvider(&vault.VaultProviderConfig{
+		ID:        p.ID,
+		Address:   p.Address,
+		Namespace: p.Namespace,
+		Mount:     p.Mount,
+		KVVersion: p.KVVersion,
+		Auth: vault.VaultAuth{
+			Method:       p.Auth.Method,
+			TokenFile:    p.Auth.TokenFile,
+			RoleID:       p.Auth.RoleID,
+			SecretIDFile: p.Auth.SecretIDFile,
+			Mount:        p.Auth.Mount,
+		},
+	}), nil
+}
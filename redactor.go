@@ -0,0 +1,222 @@
+package genv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RedactedValue replaces the secret values in the output.
+	RedactedValue = "***"
+	// MinRedactLength is the minimum length of the values the Redactor
+	// replaces. Shorter values such as "1" or "on" would mask unrelated output.
+	MinRedactLength = 4
+	// FlushDelay is how long the Redactor holds back the end of the output
+	// that may be the beginning of a value while nothing more is written.
+	FlushDelay = 100 * time.Millisecond
+)
+
+// Redactor is an io.Writer that replaces secret values in what is written to
+// it with RedactedValue before writing it to the underlying writer. The
+// standard and URL-safe base64 encodings and the URL encodings of the values,
+// as well as each line of multi-line values, are replaced too. The base64
+// encodings are replaced wherever the value starts in the encoded data, but
+// the one or two characters that also encode the data around it are kept.
+//
+// A value may be split across writes, so the Redactor holds back the end of a
+// write while it may be the beginning of a value. It writes that out when
+// nothing more is written for FlushDelay, e.g. a prompt waiting for input,
+// or on Close.
+type Redactor struct {
+	mu sync.Mutex
+	w  io.Writer
+	// patterns are the byte sequences to replace, longest first.
+	patterns  [][]byte
+	pending   []byte
+	lastWrite time.Time
+	timer     *time.Timer
+	// err is the error of writing out the pending data after FlushDelay,
+	// which the next Write or Close returns.
+	err error
+}
+
+func NewRedactor(w io.Writer, secrets []string) *Redactor {
+	var patterns [][]byte
+	add := func(s string) {
+		if len(s) < MinRedactLength {
+			return
+		}
+		if !slices.ContainsFunc(patterns, func(p []byte) bool { return string(p) == s }) {
+			patterns = append(patterns, []byte(s))
+		}
+	}
+
+	for _, secret := range secrets {
+		values := []string{secret}
+		if strings.Contains(secret, "\n") {
+			for _, line := range strings.Split(secret, "\n") {
+				values = append(values, strings.TrimSpace(line))
+			}
+		}
+
+		for _, v := range values {
+			add(v)
+			for _, enc := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+				add(enc.EncodeToString([]byte(v)))
+				for _, part := range shiftedBase64(enc, v) {
+					add(part)
+				}
+			}
+			add(url.QueryEscape(v))
+			add(url.PathEscape(v))
+		}
+	}
+
+	slices.SortStableFunc(patterns, func(a, b []byte) int { return len(b) - len(a) })
+
+	return &Redactor{w: w, patterns: patterns}
+}
+
+// shiftedBase64 returns the parts of the encodings of v that are the same
+// wherever v starts in the encoded data, for each of the three offsets of v
+// in the 3-byte groups the encoding works on.
+func shiftedBase64(enc *base64.Encoding, v string) []string {
+	var parts []string
+	for offset := range 3 {
+		encoded := enc.EncodeToString(append(make([]byte, offset), v...))
+		// Drop the characters that also encode the bytes before and after v.
+		start := (offset*8 + 5) / 6
+		end := (offset + len(v)) * 8 / 6
+		if start < end {
+			parts = append(parts, encoded[start:end])
+		}
+	}
+
+	return parts
+}
+
+func (r *Redactor) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.err; err != nil {
+		r.err = nil
+		return 0, err
+	}
+
+	out, rest := r.redact(append(r.pending, p...), false)
+	r.pending = slices.Clone(rest)
+
+	if len(out) > 0 {
+		if _, err := r.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+
+	r.lastWrite = time.Now()
+	if len(r.pending) > 0 {
+		if r.timer == nil {
+			r.timer = time.AfterFunc(FlushDelay, r.flushIdle)
+		} else {
+			r.timer.Reset(FlushDelay)
+		}
+	}
+
+	return len(p), nil
+}
+
+// flushIdle writes out the data held back if nothing was written for
+// FlushDelay.
+func (r *Redactor) flushIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A Write after the timer fired reset it.
+	if time.Since(r.lastWrite) < FlushDelay {
+		return
+	}
+
+	if err := r.flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Close writes out the data held back, without closing the underlying writer.
+func (r *Redactor) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	if err := r.err; err != nil {
+		r.err = nil
+		return err
+	}
+
+	return r.flush()
+}
+
+func (r *Redactor) flush() error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	out, _ := r.redact(r.pending, true)
+	r.pending = nil
+
+	_, err := r.w.Write(out)
+	return err
+}
+
+// redact replaces the patterns in b. Unless final is set, it stops where a
+// pattern may start once more data is written, and returns the rest.
+func (r *Redactor) redact(b []byte, final bool) (out []byte, rest []byte) {
+	var buf bytes.Buffer
+	i := 0
+	for i < len(b) {
+		if !final && r.partialMatch(b[i:]) {
+			break
+		}
+
+		if n := r.match(b[i:]); n > 0 {
+			buf.WriteString(RedactedValue)
+			i += n
+			continue
+		}
+
+		buf.WriteByte(b[i])
+		i++
+	}
+
+	return buf.Bytes(), b[i:]
+}
+
+// match returns the length of the longest pattern b starts with, or 0.
+func (r *Redactor) match(b []byte) int {
+	for _, p := range r.patterns {
+		if bytes.HasPrefix(b, p) {
+			return len(p)
+		}
+	}
+
+	return 0
+}
+
+// partialMatch reports whether b is a proper prefix of a pattern, that is,
+// whether a pattern may start at b once more data is written.
+func (r *Redactor) partialMatch(b []byte) bool {
+	for _, p := range r.patterns {
+		if len(b) < len(p) && bytes.HasPrefix(p, b) {
+			return true
+		}
+	}
+
+	return false
+}
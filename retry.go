@@ -0,0 +1,129 @@
+package genv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/mrtc0/genv/provider"
+)
+
+const (
+	// DefaultRetries is the default number of times a request to a provider
+	// is retried after a transient error.
+	DefaultRetries = 2
+	// DefaultBackoff is the default delay before the first retry.
+	DefaultBackoff = 500 * time.Millisecond
+	// MaxBackoff is the longest delay the backoff doubles up to. A longer
+	// Backoff is used as is for every retry.
+	MaxBackoff = 30 * time.Second
+)
+
+// errRequestTimeout is the error of the requests that exceed the timeout of
+// the provider.
+var errRequestTimeout = errors.New("request timed out")
+
+// retryClient retries the requests to the client that fail with a transient
+// error, waiting longer before each retry. Each request is given at most
+// timeout, if set.
+type retryClient struct {
+	client  provider.SecretClient
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+// withRetry wraps the client to apply the timeout and retry settings.
+func withRetry(client provider.SecretClient, s ProviderSettings) provider.SecretClient {
+	c := &retryClient{
+		client:  client,
+		timeout: s.Timeout,
+		retries: s.retries(),
+		backoff: s.Backoff,
+	}
+	if c.backoff <= 0 {
+		c.backoff = DefaultBackoff
+	}
+
+	if c.timeout <= 0 && c.retries <= 0 {
+		return client
+	}
+
+	return c
+}
+
+// retries returns the number of times a request is retried, which is
+// DefaultRetries if not set. The providers whose SDK retries the requests
+// turn that off when it is positive, so that the retries do not add up.
+func (s ProviderSettings) retries() int {
+	if s.Retries == nil {
+		return DefaultRetries
+	}
+
+	return *s.Retries
+}
+
+func (c *retryClient) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		secret, err := c.getSecret(ctx, ref)
+		if err == nil || attempt >= c.retries || ctx.Err() != nil || !c.isTransient(err) {
+			return secret, err
+		}
+
+		// Randomize the delay so that concurrent requests do not retry at
+		// the same time.
+		wait := delay/2 + rand.N(delay/2+1)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w, last error: %w", ctx.Err(), err)
+		}
+		delay = nextBackoff(delay)
+	}
+}
+
+// nextBackoff returns the delay after delay, which is twice as long up to
+// MaxBackoff.
+func nextBackoff(delay time.Duration) time.Duration {
+	if delay >= MaxBackoff {
+		return delay
+	}
+
+	return min(delay*2, MaxBackoff)
+}
+
+func (c *retryClient) getSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+	if c.timeout <= 0 {
+		return c.client.GetSecret(ctx, ref)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	secret, err := c.client.GetSecret(ctx, ref)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// The client may not report the timeout itself, e.g. when the
+		// command it runs is killed.
+		return nil, fmt.Errorf("%w after %s: %w", errRequestTimeout, c.timeout, err)
+	}
+
+	return secret, err
+}
+
+// isTransient reports whether the request may succeed if retried. The
+// requests that exceeded the timeout of the provider are retried too.
+func (c *retryClient) isTransient(err error) bool {
+	return provider.IsTransient(err) || errors.Is(err, errRequestTimeout)
+}
+
+// Close closes the client if it holds resources.
+func (c *retryClient) Close() error {
+	if closer, ok := c.client.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package genv
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/aws"
+	"github.com/mrtc0/genv/provider/exec"
+	"github.com/mrtc0/genv/provider/googlecloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secretClientFunc is an adapter to use a function as a SecretClient.
+type secretClientFunc func(ctx context.Context, ref provider.SecretRef) ([]byte, error)
+
+func (f secretClientFunc) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+	return f(ctx, ref)
+}
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	errThrottled := provider.Transient(errors.New("throttled"))
+	retries := func(n int) *int { return &n }
+
+	testCases := map[string]struct {
+		settings ProviderSettings
+		// errs are returned by the first calls, and the later calls return
+		// the secret after delay.
+		errs          []error
+		delay         time.Duration
+		expectedCalls int32
+		errMsg        string
+	}{
+		"transient errors are retried": {
+			settings:      ProviderSettings{Backoff: time.Millisecond},
+			errs:          []error{errThrottled, errThrottled},
+			expectedCalls: 3,
+		},
+		"retries are exhausted": {
+			settings:      ProviderSettings{Retries: retries(1), Backoff: time.Millisecond},
+			errs:          []error{errThrottled, errThrottled},
+			expectedCalls: 2,
+			errMsg:        "throttled",
+		},
+		"other errors are not retried": {
+			settings:      ProviderSettings{Backoff: time.Millisecond},
+			errs:          []error{errors.New("access denied")},
+			expectedCalls: 1,
+			errMsg:        "access denied",
+		},
+		"retries are disabled": {
+			settings:      ProviderSettings{Retries: retries(0)},
+			errs:          []error{errThrottled},
+			expectedCalls: 1,
+			errMsg:        "throttled",
+		},
+		"requests that time out are retried": {
+			settings:      ProviderSettings{Timeout: 10 * time.Millisecond, Backoff: time.Millisecond},
+			delay:         time.Second,
+			expectedCalls: 3,
+			errMsg:        "request timed out after 10ms: context deadline exceeded",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+			client := withRetry(secretClientFunc(func(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+				i := int(calls.Add(1)) - 1
+				if i < len(tt.errs) {
+					return nil, tt.errs[i]
+				}
+
+				select {
+				case <-time.After(tt.delay):
+					return []byte("this-is-a-secret"), nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}), tt.settings)
+
+			secret, err := client.GetSecret(context.Background(), provider.SecretRef{Key: "api-key"})
+			assert.Equal(t, tt.expectedCalls, calls.Load())
+			if tt.errMsg != "" {
+				require.EqualError(t, err, tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "this-is-a-secret", string(secret))
+		})
+	}
+}
+
+func TestWithRetry_StopsWhenCanceled(t *testing.T) {
+	t.Parallel()
+
+	retries := 10
+	client := withRetry(secretClientFunc(func(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+		return nil, provider.Transient(errors.New("throttled"))
+	}), ProviderSettings{Retries: &retries, Backoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetSecret(ctx, provider.SecretRef{Key: "api-key"})
+	require.EqualError(t, err, "context deadline exceeded, last error: throttled")
+}
+
+func TestWithRetry_ExecClient(t *testing.T) {
+	t.Parallel()
+
+	// The command hangs on the first run, and prints the secrets once it has
+	// left the marker.
+	marker := filepath.Join(t.TempDir(), "marker")
+	client, err := exec.NewProvider(&exec.ExecProviderConfig{
+		ID:      "exec",
+		Command: []string{"sh", "-c", `[ -e "$0" ] && echo '{"api-key":"this-is-a-secret"}' || { touch "$0"; exec sleep 10; }`, marker},
+	}).NewClient(context.Background())
+	require.NoError(t, err)
+
+	client = withRetry(client, ProviderSettings{Timeout: 200 * time.Millisecond, Backoff: time.Millisecond})
+
+	secret, err := client.GetSecret(context.Background(), provider.SecretRef{Key: "api-key"})
+	require.NoError(t, err)
+	assert.Equal(t, "this-is-a-secret", string(secret))
+}
+
+func TestNextBackoff(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		delay    time.Duration
+		expected time.Duration
+	}{
+		"doubles": {
+			delay:    500 * time.Millisecond,
+			expected: time.Second,
+		},
+		"stops at the maximum": {
+			delay:    20 * time.Second,
+			expected: MaxBackoff,
+		},
+		"keeps the maximum": {
+			delay:    MaxBackoff,
+			expected: MaxBackoff,
+		},
+		"keeps a longer delay": {
+			delay:    math.MaxInt64,
+			expected: math.MaxInt64,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, nextBackoff(tt.delay))
+		})
+	}
+}
+
+func TestWithRetry_WithoutTimeoutAndRetries(t *testing.T) {
+	t.Parallel()
+
+	retries := 0
+	client := nopSecretClient{}
+
+	assert.Equal(t, provider.SecretClient(client), withRetry(client, ProviderSettings{Retries: &retries}))
+}
+
+func TestNewProvider_DisableRetries(t *testing.T) {
+	t.Parallel()
+
+	retries := func(n int) *int { return &n }
+
+	testCases := map[string]struct {
+		settings ProviderSettings
+		expected bool
+	}{
+		"default retries":  {settings: ProviderSettings{}, expected: true},
+		"retries":          {settings: ProviderSettings{Retries: retries(3)}, expected: true},
+		"retries disabled": {settings: ProviderSettings{Retries: retries(0)}, expected: false},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := newAwsProvider(AwsProvider{ID: "aws", ProviderSettings: tt.settings})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p.(*aws.Provider).Config.DisableRetries, "aws")
+
+			p, err = newGoogleCloudProvider(GoogleCloudProvider{ID: "gcp", ProviderSettings: tt.settings})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p.(*googlecloud.Provider).Config.DisableRetries, "googleCloud")
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package genv
+
+//go:generate sh -c "go run ./cmd/genv schema > genv.schema.json"
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/mrtc0/genv/provider/aws"
+	"github.com/mrtc0/genv/provider/onepassword"
+	"github.com/mrtc0/genv/provider/vault"
+)
+
+// SchemaID is the URL the JSON Schema of the config file is published at.
+const SchemaID = "https://raw.githubusercontent.com/mrtc0/genv/main/genv.schema.json"
+
+// schemaOverrides holds the schemas of the types that cannot be derived from
+// their Go type, such as enums and types with a custom UnmarshalYAML.
+var schemaOverrides = map[reflect.Type]map[string]any{
+	reflect.TypeFor[ExecCommand](): {
+		"oneOf": []any{
+			map[string]any{"type": "string", "description": "A command run with sh -c"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "minItems": 1, "description": "A command run directly without a shell"},
+		},
+	},
+	reflect.TypeFor[aws.AwsSecretService](): {
+		"type": "string",
+		"enum": []any{aws.AWSSecretsManager, aws.SSMParameterStore},
+	},
+	reflect.TypeFor[onepassword.OnePasswordAuthMethod](): {
+		"type": "string",
+		"enum": []any{onepassword.OnePasswordAuthMethodCLI, onepassword.OnePasswordAuthMethodServiceAccount},
+	},
+	reflect.TypeFor[vault.AuthMethod](): {
+		"type": "string",
+		"enum": []any{vault.AuthMethodToken, vault.AuthMethodTokenFile, vault.AuthMethodAppRole},
+	},
+	reflect.TypeFor[time.Duration](): {
+		"type":        "string",
+		"pattern":     `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+		"description": "A duration such as 500ms, 10s or 1m30s",
+	},
+	reflect.TypeFor[KeyCase](): {
+		"type": "string",
+		"enum": []any{KeyCaseUpper, KeyCaseLower},
+	},
+}
+
+// JSONSchema returns the JSON Schema of the config file. It is generated from
+// the Config type: each struct becomes an object that allows only its YAML
+// fields, and fields tagged with `jsonschema:"required"` are required.
+func JSONSchema() ([]byte, error) {
+	g := &schemaGenerator{defs: make(map[string]any)}
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     SchemaID,
+		"title":   "genv config",
+		"$ref":    g.schema(reflect.TypeFor[Config]())["$ref"],
+		"$defs":   g.defs,
+	}
+
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+type schemaGenerator struct {
+	// defs holds the schemas of the structs by name.
+	defs map[string]any
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if s, ok := schemaOverrides[t]; ok {
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+		if _, ok := g.defs[t.Name()]; ok {
+			return ref
+		}
+
+		// Register the name before generating the fields in case the struct
+		// refers to itself.
+		g.defs[t.Name()] = nil
+		if t == reflect.TypeFor[SecretProvider]() {
+			g.defs[t.Name()] = g.secretProviderSchema()
+		} else {
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+
+		return ref
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+
+	for _, f := range yamlFields(t) {
+		properties[f.name] = g.schema(f.field.Type)
+		if f.field.Tag.Get("jsonschema") == "required" {
+			required = append(required, f.name)
+		}
+	}
+
+	s := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+
+	return s
+}
+
+// secretProviderSchema describes the providers of the types that have a
+// ConfigType.
+func (g *schemaGenerator) secretProviderSchema() map[string]any {
+	providerTypesMu.RLock()
+	defer providerTypesMu.RUnlock()
+
+	properties := make(map[string]any)
+	for name, t := range providerTypes {
+		if t.ConfigType != nil {
+			properties[name] = map[string]any{"type": "array", "items": g.schema(t.ConfigType)}
+		}
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
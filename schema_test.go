@@ -0,0 +1,41 @@
+package genv_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/mrtc0/genv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := genv.JSONSchema()
+	require.NoError(t, err)
+
+	published, err := os.ReadFile("genv.schema.json")
+	require.NoError(t, err)
+	assert.Equal(t, string(published), string(schema), "genv.schema.json is out of date. Run `go generate ./...` to update it.")
+
+	var s struct {
+		Ref  string `json:"$ref"`
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(schema, &s))
+
+	assert.Equal(t, "#/$defs/Config", s.Ref)
+	assert.Contains(t, s.Defs["Config"].Properties, "secretProvider")
+	// Inlined provider settings are part of the provider
+	assert.Contains(t, s.Defs["ExecProvider"].Properties, "concurrency")
+	assert.JSONEq(t, `{"oneOf": [
+		{"type": "string", "description": "A command run with sh -c"},
+		{"type": "array", "items": {"type": "string"}, "minItems": 1, "description": "A command run directly without a shell"}
+	]}`, string(s.Defs["ExecProvider"].Properties["command"]))
+	assert.Equal(t, []string{"provider", "key"}, s.Defs["SecretRef"].Required)
+}
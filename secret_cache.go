@@ -0,0 +1,68 @@
+package genv
+
+import (
+	"context"
+	"sync"
+)
+
+type secretCacheContextKey struct{}
+
+type secretCacheKey struct {
+	providerID string
+	key        string
+}
+
+// secretCache holds the raw secret payloads fetched during a single request.
+// Concurrent lookups of the same key wait for the first fetch to complete
+// instead of fetching the secret again.
+type secretCache struct {
+	mu      sync.Mutex
+	entries map[secretCacheKey]*secretCacheEntry
+}
+
+type secretCacheEntry struct {
+	ready  chan struct{}
+	secret []byte
+	err    error
+}
+
+// WithSecretCache returns a copy of ctx that caches the raw secret payloads
+// retrieved by SecretProviderService.GetSecret. While the returned context is
+// in use, each secret is fetched from its provider at most once, regardless of
+// how many properties are read from it.
+func WithSecretCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, secretCacheContextKey{}, &secretCache{
+		entries: make(map[secretCacheKey]*secretCacheEntry),
+	})
+}
+
+func secretCacheFromContext(ctx context.Context) *secretCache {
+	c, _ := ctx.Value(secretCacheContextKey{}).(*secretCache)
+	return c
+}
+
+// getOrFetch returns the cached secret for key, calling fetch if it has not
+// been fetched yet.
+func (c *secretCache) getOrFetch(ctx context.Context, key secretCacheKey, fetch func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &secretCacheEntry{ready: make(chan struct{})}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case <-entry.ready:
+			return entry.secret, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	entry.secret, entry.err = fetch()
+	close(entry.ready)
+
+	return entry.secret, entry.err
+}
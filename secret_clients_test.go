@@ -0,0 +1,208 @@
+package genv_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	smpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/1password/onepassword-sdk-go"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/mrtc0/genv"
+	"github.com/mrtc0/genv/provider"
+	"github.com/mrtc0/genv/provider/aws/parameterstore"
+	"github.com/mrtc0/genv/provider/aws/secretsmanager"
+	"github.com/mrtc0/genv/provider/exec"
+	gcpsm "github.com/mrtc0/genv/provider/googlecloud/secretmanager"
+	"github.com/mrtc0/genv/provider/onepassword/op"
+	"github.com/mrtc0/genv/provider/onepassword/sdk"
+	"github.com/mrtc0/genv/provider/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDotenvGenerator_FetchSecrets_SecretClients fetches many secrets
+// concurrently through each SecretClient implementation, backed by a fake
+// service, to check with -race that the clients are safe for concurrent use.
+func TestDotenvGenerator_FetchSecrets_SecretClients(t *testing.T) {
+	t.Parallel()
+
+	const secrets = 20
+
+	// Each fake service serves the secret "value-of-<key>" for any key, as
+	// the property "value" of a JSON secret if the service stores JSON.
+	testCases := map[string]struct {
+		newClient func(t *testing.T) provider.SecretClient
+		property  string
+	}{
+		"aws secrets manager": {
+			newClient: func(t *testing.T) provider.SecretClient {
+				return secretsmanager.NewSecretsManager(newFakeAWSConfig(t, func(body map[string]any) any {
+					key := body["SecretId"].(string)
+					return map[string]any{"Name": key, "SecretString": jsonSecret(key)}
+				}))
+			},
+			property: "value",
+		},
+		"aws parameter store": {
+			newClient: func(t *testing.T) provider.SecretClient {
+				return parameterstore.NewParameterStore(newFakeAWSConfig(t, func(body map[string]any) any {
+					key := body["Name"].(string)
+					return map[string]any{"Parameter": map[string]any{"Name": key, "Type": "SecureString", "Value": jsonSecret(key)}}
+				}))
+			},
+			property: "value",
+		},
+		"google cloud secret manager": {
+			newClient: func(t *testing.T) provider.SecretClient {
+				return &gcpsm.SecretManagerClient{ProjectID: "my-project", Client: fakeGoogleCloudSecretManager{}}
+			},
+			property: "value",
+		},
+		"vault": {
+			newClient: func(t *testing.T) provider.SecretClient {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					key := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+					_ = json.NewEncoder(w).Encode(map[string]any{
+						"data": map[string]any{"data": map[string]any{"value": "value-of-" + key}},
+					})
+				}))
+				t.Cleanup(ts.Close)
+
+				tokenFile := filepath.Join(t.TempDir(), "token")
+				require.NoError(t, os.WriteFile(tokenFile, []byte("dummy-token"), 0600))
+
+				client, err := vault.NewProvider(&vault.VaultProviderConfig{
+					ID:      "vault",
+					Address: ts.URL,
+					Auth:    vault.VaultAuth{Method: vault.AuthMethodTokenFile, TokenFile: tokenFile},
+				}).NewClient(context.Background())
+				require.NoError(t, err)
+
+				return client
+			},
+			property: "value",
+		},
+		"1password cli": {
+			newClient: func(t *testing.T) provider.SecretClient {
+				client := op.NewOPClient("my-account")
+				client.Executor = fakeOPCommandExecutor{}
+				return client
+			},
+		},
+		"1password sdk": {
+			newClient: func(t *testing.T) provider.SecretClient {
+				return &sdk.OnePasswordClient{Secrets: fakeOnePasswordSecrets{}}
+			},
+		},
+		"exec": {
+			newClient: func(t *testing.T) provider.SecretClient {
+				values := make(map[string]string, secrets)
+				for i := range secrets {
+					key := fmt.Sprintf("key-%02d", i)
+					values[key] = "value-of-" + key
+				}
+				output, err := json.Marshal(values)
+				require.NoError(t, err)
+
+				client, err := exec.NewProvider(&exec.ExecProviderConfig{
+					ID:      "exec",
+					Command: []string{"sh", "-c", `printf '%s' "$0"`, string(output)},
+				}).NewClient(context.Background())
+				require.NoError(t, err)
+
+				return client
+			},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config := &genv.Config{
+				Concurrency: secrets,
+				Envs:        map[string]genv.EnvValue{},
+			}
+			expected := make(map[string]string, secrets)
+			for i := range secrets {
+				key := fmt.Sprintf("key-%02d", i)
+				config.Envs[fmt.Sprintf("SECRET_%02d", i)] = genv.EnvValue{
+					SecretRef: &genv.SecretRef{Provider: "provider", Key: key, Property: tt.property},
+				}
+				expected[fmt.Sprintf("SECRET_%02d", i)] = "value-of-" + key
+			}
+
+			svc := &genv.SecretProviderService{}
+			svc.AddSecretProviderClient("provider", tt.newClient(t))
+
+			generator := &genv.DotenvGenerator{
+				Config:                config,
+				SecretProviderService: svc,
+			}
+
+			got, err := generator.FetchSecrets(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, expected, got)
+		})
+	}
+}
+
+func jsonSecret(key string) string {
+	return `{"value":"value-of-` + key + `"}`
+}
+
+// newFakeAWSConfig returns the config of an AWS client that sends the
+// requests to a fake service, which responds with the result of respond
+// for the JSON body of each request.
+func newFakeAWSConfig(t *testing.T, respond func(body map[string]any) any) awssdk.Config {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_ = json.NewEncoder(w).Encode(respond(body))
+	}))
+	t.Cleanup(ts.Close)
+
+	return awssdk.Config{
+		Region:       "us-east-1",
+		Credentials:  awssdk.AnonymousCredentials{},
+		BaseEndpoint: awssdk.String(ts.URL),
+	}
+}
+
+type fakeGoogleCloudSecretManager struct{}
+
+func (fakeGoogleCloudSecretManager) AccessSecretVersion(ctx context.Context, req *smpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*smpb.AccessSecretVersionResponse, error) {
+	key := strings.Split(req.Name, "/")[3]
+	return &smpb.AccessSecretVersionResponse{Payload: &smpb.SecretPayload{Data: []byte(jsonSecret(key))}}, nil
+}
+
+type fakeOPCommandExecutor struct{}
+
+func (fakeOPCommandExecutor) Exec(ctx context.Context, args []string) ([]byte, error) {
+	return []byte("value-of-" + args[len(args)-1]), nil
+}
+
+type fakeOnePasswordSecrets struct{}
+
+func (fakeOnePasswordSecrets) Resolve(ctx context.Context, secretReference string) (string, error) {
+	return "value-of-" + secretReference, nil
+}
+
+func (fakeOnePasswordSecrets) ResolveAll(ctx context.Context, secretReferences []string) (onepassword.ResolveAllResponse, error) {
+	return onepassword.ResolveAllResponse{}, fmt.Errorf("not implemented")
+}
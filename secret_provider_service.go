@@ -2,95 +2,137 @@ package genv
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/mrtc0/genv/provider"
-	"github.com/mrtc0/genv/provider/aws"
-	"github.com/mrtc0/genv/provider/exec"
-	"github.com/mrtc0/genv/provider/googlecloud"
-	"github.com/mrtc0/genv/provider/onepassword"
+	"github.com/mrtc0/genv/provider/secretutil"
 )
 
 type SecretProviderService struct {
-	clients map[string]provider.SecretClient
+	clients map[string]*lazyClient
+	// limits holds a semaphore per provider ID that has a concurrency limit.
+	limits map[string]chan struct{}
 }
 
-func NewSecretProviderService(ctx context.Context, sp SecretProvider) (*SecretProviderService, error) {
-	secretProviderClients := make(map[string]provider.SecretClient)
-
-	for _, p := range sp.Aws {
-		providerConfig := &aws.AwsProviderConfig{
-			ID:      p.ID,
-			Service: aws.AWSSecretsManager,
-			Region:  p.Region,
-			Auth: aws.AwsAuth{
-				Profile: p.Auth.Profile,
-			},
-		}
+// lazyClient creates the client of a provider on first use, so that the
+// providers no env refers to are never set up, and a misconfigured provider
+// only fails the envs that use it.
+type lazyClient struct {
+	mu     sync.Mutex
+	create func(ctx context.Context) (provider.SecretClient, error)
+	client provider.SecretClient
+	err    error
+	// done reports whether client and err are set for good.
+	done bool
+}
 
-		awsProvider := aws.NewProvider(providerConfig)
-		client, err := awsProvider.NewClient(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create secret client: %w", err)
-		}
+func (c *lazyClient) get(ctx context.Context) (provider.SecretClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		secretProviderClients[p.ID] = client
+	if c.done {
+		return c.client, c.err
 	}
 
-	for _, p := range sp.GoogleCloud {
-		providerConfig := googlecloud.GoogleCloudProvider{
-			ID:        p.ID,
-			Service:   p.Service,
-			ProjectID: p.ProjectID,
-			Location:  p.Location,
-		}
+	client, err := c.create(ctx)
+	// The creation that failed because the call was canceled or timed out
+	// is tried again on the next call, with the context of that call.
+	if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return nil, err
+	}
+	c.client, c.err, c.done = client, err, true
 
-		gc := googlecloud.NewProvider(&providerConfig)
-		client, err := gc.NewClient(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Google Cloud secret client: %w", err)
-		}
+	return c.client, c.err
+}
 
-		secretProviderClients[p.ID] = client
+// NewSecretProviderService returns a service for the providers. The client
+// of each provider is created on the first GetSecret for it, and retries the
+// requests as configured in the ProviderSettings of the provider.
+func NewSecretProviderService(ctx context.Context, sp SecretProvider) (*SecretProviderService, error) {
+	svc := &SecretProviderService{
+		clients: make(map[string]*lazyClient),
 	}
 
-	for _, p := range sp.OnePassword {
-		opProvider := onepassword.NewProvider(
-			onepassword.WithAccount(p.Auth.Account),
-			onepassword.WithAuthMethod(p.Auth.Method),
-		)
-		client, err := opProvider.NewClient(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create 1Password secret client: %w", err)
+	for _, entry := range sp.providers() {
+		id := entry.config.ProviderID()
+
+		t, ok := lookupProviderType(entry.kind)
+		if !ok {
+			return nil, fmt.Errorf("unknown provider type %s for %s", entry.kind, id)
 		}
 
-		secretProviderClients[p.ID] = client
+		config := entry.config
+		svc.clients[id] = &lazyClient{
+			create: func(ctx context.Context) (provider.SecretClient, error) {
+				client, err := newSecretClient(ctx, t, config)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create %s secret client for %s%s: %w", t.displayName(), id, describeSources(sp.providerSources(id)...), err)
+				}
+
+				return withRetry(client, config.Settings()), nil
+			},
+		}
+		svc.SetConcurrencyLimit(id, config.Settings().Concurrency)
 	}
 
-	for _, p := range sp.Exec {
-		execProvider := exec.NewProvider(&exec.ExecProviderConfig{
-			ID:      p.ID,
-			Command: p.Command.Args,
-		})
-		client, err := execProvider.NewClient(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create exec secret client: %w", err)
+	return svc, nil
+}
+
+// Close releases the clients that hold resources, such as the processes of
+// plugins. No client is created after Close.
+func (s *SecretProviderService) Close() error {
+	var errs []error
+	for _, c := range s.clients {
+		// Wait for the client being created, if any, and keep the clients
+		// not created yet from being created.
+		c.mu.Lock()
+		if !c.done {
+			c.err, c.done = errors.New("secret provider service is closed"), true
 		}
+		client := c.client
+		c.mu.Unlock()
 
-		secretProviderClients[p.ID] = client
+		if closer, ok := client.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
 	}
 
-	return &SecretProviderService{
-		clients: secretProviderClients,
-	}, nil
+	return errors.Join(errs...)
+}
+
+func newSecretClient(ctx context.Context, t ProviderType, config ProviderConfig) (provider.SecretClient, error) {
+	p, err := t.New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.NewClient(ctx)
 }
 
 func (s *SecretProviderService) AddSecretProviderClient(providerID string, client provider.SecretClient) {
 	if s.clients == nil {
-		s.clients = make(map[string]provider.SecretClient)
+		s.clients = make(map[string]*lazyClient)
+	}
+
+	s.clients[providerID] = &lazyClient{client: client, done: true}
+}
+
+// SetConcurrencyLimit limits the number of concurrent GetSecret calls to the
+// provider. A limit of zero or less removes the limit.
+func (s *SecretProviderService) SetConcurrencyLimit(providerID string, limit int) {
+	if s.limits == nil {
+		s.limits = make(map[string]chan struct{})
+	}
+
+	if limit <= 0 {
+		delete(s.limits, providerID)
+		return
 	}
 
-	s.clients[providerID] = client
+	s.limits[providerID] = make(chan struct{}, limit)
 }
 
 type GetSecretInput struct {
@@ -98,16 +140,82 @@ type GetSecretInput struct {
 	Property string
 }
 
+// GetSecret retrieves the secret identified by input.Key from the provider and,
+// if input.Property is set, extracts the property from the JSON secret.
+//
+// If ctx carries a cache created by WithSecretCache, the raw secret is fetched
+// only once per provider and key, no matter how many properties are read.
 func (s *SecretProviderService) GetSecret(ctx context.Context, providerID string, input GetSecretInput) ([]byte, error) {
-	ref := provider.SecretRef{
-		Key:      input.Key,
-		Property: input.Property,
-	}
-
 	client, ok := s.clients[providerID]
 	if !ok {
 		return nil, fmt.Errorf("secret provider client not found for ID: %s", providerID)
 	}
 
-	return client.GetSecret(ctx, ref)
+	fetch := func() ([]byte, error) {
+		return s.fetch(ctx, providerID, client, input.Key)
+	}
+
+	var (
+		secret []byte
+		err    error
+	)
+	if cache := secretCacheFromContext(ctx); cache != nil {
+		secret, err = cache.getOrFetch(ctx, secretCacheKey{providerID: providerID, key: input.Key}, fetch)
+	} else {
+		secret, err = fetch()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Property == "" {
+		return secret, nil
+	}
+
+	val, err := secretutil.GetValueFromJSON(secret, input.Property)
+	if err != nil {
+		return nil, fmt.Errorf("property %q not found in secret %s: %w", input.Property, input.Key, err)
+	}
+
+	return val, nil
+}
+
+// fetch retrieves the raw secret payload from the client, creating it on
+// first use, and honoring the provider's concurrency limit.
+func (s *SecretProviderService) fetch(ctx context.Context, providerID string, lazy *lazyClient, key string) ([]byte, error) {
+	client, err := lazy.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The slot of the provider is taken before the global one, so that the
+	// requests waiting for a busy provider do not hold the global slots the
+	// requests to the other providers could use.
+	for _, sem := range []chan struct{}{s.limits[providerID], concurrencyLimitFromContext(ctx)} {
+		if sem == nil {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return client.GetSecret(ctx, provider.SecretRef{Key: key})
+}
+
+type concurrencyLimitContextKey struct{}
+
+// withConcurrencyLimit returns a copy of ctx that limits the number of
+// GetSecret calls in flight across all providers.
+func withConcurrencyLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, concurrencyLimitContextKey{}, make(chan struct{}, limit))
+}
+
+func concurrencyLimitFromContext(ctx context.Context) chan struct{} {
+	sem, _ := ctx.Value(concurrencyLimitContextKey{}).(chan struct{})
+	return sem
 }
@@ -0,0 +1,94 @@
+package genv
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mrtc0/genv/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopSecretClient struct{}
+
+func (nopSecretClient) GetSecret(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+	return nil, nil
+}
+
+func TestLazyClient_Get(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	timedOut, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	testCases := map[string]struct {
+		// firstCtx is the context of the first call, which fails if it is
+		// done. The second call is made with a live context.
+		firstCtx  context.Context
+		createErr error
+		// expectedCreates is the number of times the client is created
+		// over the two calls.
+		expectedCreates int32
+		errMsg          string
+	}{
+		"canceled creation is tried again": {
+			firstCtx:        canceled,
+			expectedCreates: 2,
+		},
+		"timed out creation is tried again": {
+			firstCtx:        timedOut,
+			expectedCreates: 2,
+		},
+		"timeout of the provider is tried again": {
+			firstCtx:        context.Background(),
+			createErr:       context.DeadlineExceeded,
+			expectedCreates: 2,
+			errMsg:          "context deadline exceeded",
+		},
+		"other errors are kept": {
+			firstCtx:        context.Background(),
+			createErr:       errors.New("invalid credentials"),
+			expectedCreates: 1,
+			errMsg:          "invalid credentials",
+		},
+		"client is kept": {
+			firstCtx:        context.Background(),
+			expectedCreates: 1,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var creates atomic.Int32
+			c := &lazyClient{
+				create: func(ctx context.Context) (provider.SecretClient, error) {
+					creates.Add(1)
+					if err := ctx.Err(); err != nil {
+						return nil, err
+					}
+					if tt.createErr != nil {
+						return nil, tt.createErr
+					}
+					return nopSecretClient{}, nil
+				},
+			}
+
+			_, _ = c.get(tt.firstCtx)
+			client, err := c.get(context.Background())
+			assert.Equal(t, tt.expectedCreates, creates.Load())
+			if tt.errMsg != "" {
+				require.EqualError(t, err, tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, nopSecretClient{}, client)
+		})
+	}
+}
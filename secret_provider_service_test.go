@@ -2,11 +2,14 @@ package genv_test
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/mrtc0/genv"
 	"github.com/mrtc0/genv/provider"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSecretProviderService_GetSecret(t *testing.T) {
@@ -39,6 +42,19 @@ func TestSecretProviderService_GetSecret(t *testing.T) {
 					returnSecretValue: []byte("example-secret"),
 				},
 			},
+			args: genv.GetSecretInput{
+				Key: "example-key",
+			},
+			want:    []byte("example-secret"),
+			wantErr: false,
+		},
+		"when property is specified": {
+			arrange: arrange{
+				providerID: "example-account",
+				client: &mockSecretClient{
+					returnSecretValue: []byte(`{"example-property": "example-secret"}`),
+				},
+			},
 			args: genv.GetSecretInput{
 				Key:      "example-key",
 				Property: "example-property",
@@ -46,6 +62,20 @@ func TestSecretProviderService_GetSecret(t *testing.T) {
 			want:    []byte("example-secret"),
 			wantErr: false,
 		},
+		"when property is not found": {
+			arrange: arrange{
+				providerID: "example-account",
+				client: &mockSecretClient{
+					returnSecretValue: []byte(`{"example-property": "example-secret"}`),
+				},
+			},
+			args: genv.GetSecretInput{
+				Key:      "example-key",
+				Property: "non-existing-property",
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 
 	for name, tt := range testCases {
@@ -72,3 +102,81 @@ func TestSecretProviderService_GetSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestSecretProviderService_GetSecret_WithSecretCache(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	client := &mockSecretClient{
+		getSecretFunc: func(ctx context.Context, ref provider.SecretRef) ([]byte, error) {
+			calls.Add(1)
+			assert.Empty(t, ref.Property)
+			return []byte(`{"username": "user", "password": "pass", "host": "db.example.com"}`), nil
+		},
+	}
+
+	s := &genv.SecretProviderService{}
+	s.AddSecretProviderClient("example-account", client)
+
+	ctx := genv.WithSecretCache(context.Background())
+
+	var wg sync.WaitGroup
+	for _, property := range []string{"username", "password", "host", "username"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := s.GetSecret(ctx, "example-account", genv.GetSecretInput{Key: "db-credentials", Property: property})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	got, err := s.GetSecret(ctx, "example-account", genv.GetSecretInput{Key: "db-credentials", Property: "host"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("db.example.com"), got)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Without the cache, every call reaches the provider.
+	_, err = s.GetSecret(context.Background(), "example-account", genv.GetSecretInput{Key: "db-credentials", Property: "host"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestNewSecretProviderService_Lazy(t *testing.T) {
+	t.Parallel()
+
+	var created atomic.Int32
+	sp := genv.NewSecretProvider(map[string][]genv.ProviderConfig{
+		"exec":   {genv.ExecProvider{ID: "broken"}},
+		"plugin": {genv.PluginProvider{ID: "missing", Path: "./does-not-exist"}},
+		"static": {staticProviderConfig{
+			ID:      "fixture",
+			Secrets: map[string]string{"api-key": "this-is-a-secret"},
+			created: &created,
+		}},
+	})
+
+	// The misconfigured providers do not fail until they are used
+	svc, err := genv.NewSecretProviderService(context.Background(), sp)
+	require.NoError(t, err)
+	defer svc.Close()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			secret, err := svc.GetSecret(context.Background(), "fixture", genv.GetSecretInput{Key: "api-key"})
+			assert.NoError(t, err)
+			assert.Equal(t, "this-is-a-secret", string(secret))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), created.Load())
+
+	_, err = svc.GetSecret(context.Background(), "broken", genv.GetSecretInput{Key: "api-key"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create exec secret client for broken")
+}
@@ -0,0 +1,249 @@
+package genv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateData is the data passed to the templates of envs.
+type templateData struct {
+	// Env holds the resolved values of the other envs.
+	Env map[string]string
+}
+
+// templateEnv is an env whose value is rendered from a template.
+type templateEnv struct {
+	name string
+	tmpl *template.Template
+	// deps are the names of the envs referenced by the template.
+	deps []string
+	// secrets are the secrets referenced by the template with literal arguments.
+	secrets []SecretRef
+}
+
+// templateFuncs returns the functions available in templates.
+//
+//	secret "provider-id" "key" [".property"]
+//
+// retrieves a secret in the same way as secretRef does.
+func (d *DotenvGenerator) templateFuncs(ctx context.Context) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(providerID, key string, property ...string) (string, error) {
+			if len(property) > 1 {
+				return "", fmt.Errorf("secret: too many arguments")
+			}
+
+			input := GetSecretInput{Key: key}
+			if len(property) == 1 {
+				input.Property = property[0]
+			}
+
+			secret, err := d.SecretProviderService.GetSecret(ctx, providerID, input)
+			if err != nil {
+				return "", err
+			}
+
+			return string(secret), nil
+		},
+	}
+}
+
+// parseOnlyTemplateFuncs are the functions used to parse templates without
+// rendering them. The secret function is never called; it only has to exist
+// for the templates to be parsed.
+var parseOnlyTemplateFuncs = template.FuncMap{
+	"secret": func(providerID, key string, property ...string) (string, error) { return "", nil },
+}
+
+func parseTemplateEnv(name, text string, funcs template.FuncMap) (*templateEnv, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &templateEnv{name: name, tmpl: tmpl}
+
+	deps := make(map[string]struct{})
+	walkTemplate(tmpl.Root, func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.FieldNode:
+			// {{ .Env.NAME }}
+			if len(n.Ident) >= 2 && n.Ident[0] == "Env" {
+				deps[n.Ident[1]] = struct{}{}
+			}
+		case *parse.CommandNode:
+			if len(n.Args) == 0 {
+				return
+			}
+			ident, ok := n.Args[0].(*parse.IdentifierNode)
+			if !ok {
+				return
+			}
+
+			switch ident.Ident {
+			case "index":
+				// {{ index .Env "NAME" }}
+				if len(n.Args) < 3 {
+					return
+				}
+				if field, ok := n.Args[1].(*parse.FieldNode); ok && len(field.Ident) == 1 && field.Ident[0] == "Env" {
+					if s, ok := n.Args[2].(*parse.StringNode); ok {
+						deps[s.Text] = struct{}{}
+					}
+				}
+			case "secret":
+				// {{ secret "provider" "key" ".property" }}
+				var args []string
+				for _, arg := range n.Args[1:] {
+					s, ok := arg.(*parse.StringNode)
+					if !ok {
+						return
+					}
+					args = append(args, s.Text)
+				}
+				if len(args) >= 2 {
+					env.secrets = append(env.secrets, SecretRef{Provider: args[0], Key: args[1]})
+				}
+			}
+		}
+	})
+
+	for dep := range deps {
+		env.deps = append(env.deps, dep)
+	}
+	sort.Strings(env.deps)
+
+	return env, nil
+}
+
+func (t *templateEnv) render(envMap map[string]string) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, templateData{Env: envMap}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// templateCycleError is the error of template envs that depend on each other.
+type templateCycleError struct {
+	// envs are the envs in the cycle, starting and ending with the same env.
+	envs []string
+}
+
+func (e *templateCycleError) Error() string {
+	return "template dependency cycle detected: " + strings.Join(e.envs, " -> ")
+}
+
+// sortTemplateEnvs returns the names of the template envs in an order in
+// which every env comes after the template envs it depends on. The order is
+// deterministic. An error is returned if the dependencies contain a cycle.
+func sortTemplateEnvs(envs map[string]*templateEnv) ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	state := make(map[string]int, len(envs))
+	sorted := make([]string, 0, len(envs))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			i := 0
+			for path[i] != name {
+				i++
+			}
+			return &templateCycleError{envs: append(append([]string{}, path[i:]...), name)}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, dep := range envs[name].deps {
+			if _, ok := envs[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+		sorted = append(sorted, name)
+
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+func walkTemplate(node parse.Node, fn func(parse.Node)) {
+	if node == nil {
+		return
+	}
+
+	fn(node)
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplate(child, fn)
+		}
+	case *parse.ActionNode:
+		walkTemplate(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkTemplate(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplate(arg, fn)
+		}
+	case *parse.ChainNode:
+		walkTemplate(n.Node, fn)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkTemplate(n.Pipe, fn)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, fn func(parse.Node)) {
+	walkTemplate(n.Pipe, fn)
+	walkTemplate(n.List, fn)
+	walkTemplate(n.ElseList, fn)
+}